@@ -1,8 +1,7 @@
 package govaluate
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"sync"
 )
 
@@ -18,7 +17,7 @@ are an expression that can be evaluated down into a single value.
 type EvaluableExpression struct {
 
 	/*
-		Represents the query format used to output dates. Typically only used when creating SQL or Mongo queries from an expression.
+		Represents the query format used to output dates. Typically only used when creating SQL queries from an expression; Mongo and Elasticsearch queries are given times as they are.
 		Defaults to the complete ISO8601 format, including nanoseconds.
 	*/
 	QueryDateFormat string
@@ -36,6 +35,10 @@ type EvaluableExpression struct {
 	tokens           []ExpressionToken
 	evaluationStages *evaluationStage
 	inputExpression  string
+	options          ExpressionOptions
+
+	// where each token came from in [inputExpression]. Nil for expressions created from tokens.
+	positions *tokenPositions
 }
 
 /*
@@ -60,12 +63,12 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 	ret = new(EvaluableExpression)
 	ret.QueryDateFormat = isoDateFormat
 
-	err = checkBalance(tokens)
+	err = checkBalance(tokens, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = checkExpressionSyntax(tokens)
+	err = checkExpressionSyntax(tokens, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +78,7 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 		return nil, err
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(ret.tokens, nil, ret.options)
 	if err != nil {
 		return nil, err
 	}
@@ -90,24 +93,42 @@ Functions passed into this will be available to the expression.
 */
 func NewEvaluableExpressionWithFunctions(expression string, functions map[string]ExpressionFunction) (*EvaluableExpression, error) {
 
+	return newEvaluableExpression(expression, functions, nil, ExpressionOptions{})
+}
+
+/*
+Similar to [NewEvaluableExpressionWithFunctions], except that the given functions also receive the context
+that the expression is evaluated with (see `EvalContext`).
+*/
+func NewEvaluableExpressionWithContextFunctions(expression string, functions map[string]ContextExpressionFunction) (*EvaluableExpression, error) {
+
+	return newEvaluableExpression(expression, nil, functions, ExpressionOptions{})
+}
+
+func newEvaluableExpression(expression string, functions map[string]ExpressionFunction, contextFunctions map[string]ContextExpressionFunction, options ExpressionOptions) (*EvaluableExpression, error) {
+
 	var ret *EvaluableExpression
 	var err error
 
 	ret = new(EvaluableExpression)
 	ret.QueryDateFormat = isoDateFormat
 	ret.inputExpression = expression
+	ret.options = options
 
-	ret.tokens, err = parseTokens(expression, functions)
+	var positions *tokenPositions
+
+	ret.tokens, positions, err = parseTokens(expression, functions, contextFunctions, options)
+	ret.positions = positions
 	if err != nil {
 		return nil, err
 	}
 
-	err = checkBalance(ret.tokens)
+	err = checkBalance(ret.tokens, positions)
 	if err != nil {
 		return nil, err
 	}
 
-	err = checkExpressionSyntax(ret.tokens)
+	err = checkExpressionSyntax(ret.tokens, positions)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +138,7 @@ func NewEvaluableExpressionWithFunctions(expression string, functions map[string
 		return nil, err
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(ret.tokens, positions, options)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +170,7 @@ Runs the entire expression using the given [parameters].
 e.g., If the expression contains a reference to the variable "foo", it will be taken from `parameters.Get("foo")`.
 
 This function returns errors if the combination of expression and parameters cannot be run,
-such as if a variable in the expression is not present in [parameters].
+such as if a variable in the expression is not present in [parameters] (unless the expression's `MissingParametersAsNil` option is set).
 
 In all non-error circumstances, this returns the single value result of the expression and parameters given.
 e.g., if the expression is "1 + 1", this will return 2.0.
@@ -157,39 +178,74 @@ e.g., if the expression is "foo + 1" and parameters contains "foo" = 2, this wil
 */
 func (e EvaluableExpression) Eval(parameters Parameters) (interface{}, error) {
 
+	return e.EvalContext(context.Background(), parameters)
+}
+
+/*
+Same as `Eval`, but evaluation is bound to the given [ctx].
+Before each stage of the expression is run, [ctx] is checked; if it has been cancelled or its deadline has passed,
+evaluation stops and the context's error is returned.
+
+The context is also given to any `ContextExpressionFunction`, and to any accessor method whose first argument is a `context.Context`.
+*/
+func (e EvaluableExpression) EvalContext(ctx context.Context, parameters Parameters) (interface{}, error) {
+
 	if e.evaluationStages == nil {
 		return nil, nil
 	}
 
-	free := false
-	if parameters != nil {
-		free = true
-		tmp := sanitizedParamsPool.Get().(*sanitizedParameters)
-		tmp.orig = parameters
-		parameters = tmp
-	} else {
+	if parameters == nil {
 		parameters = DUMMY_PARAMETERS
 	}
 
-	ret, err := e.evaluateStage(e.evaluationStages, parameters)
-	if free {
-		sanitizedParamsPool.Put(parameters)
-	}
+	sanitized := sanitizedParamsPool.Get().(*sanitizedParameters)
+	sanitized.orig = parameters
+	sanitized.ctx = ctx
+	sanitized.numerics = e.options.Numerics
+	sanitized.missingAsNil = e.options.MissingParametersAsNil
+	sanitized.fields = fieldNamingFor(e.options)
+	sanitized.location = e.options.Location
+
+	ret, err := e.evaluateStage(ctx, e.evaluationStages, sanitized)
+
+	sanitized.orig = nil
+	sanitized.ctx = nil
+	sanitized.numerics = FLOAT_NUMERICS
+	sanitized.missingAsNil = false
+	sanitized.fields = fieldNaming{}
+	sanitized.location = nil
+	sanitizedParamsPool.Put(sanitized)
 	return ret, err
 }
 
-func (e EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+func (e EvaluableExpression) evaluateStage(ctx context.Context, stage *evaluationStage, parameters Parameters) (interface{}, error) {
 
 	var left, right interface{}
 	var err error
 
+	// Background and TODO contexts have no Done channel, so the common case costs nothing here.
+	if done := ctx.Done(); done != nil {
+		select {
+		case <-done:
+			return nil, ctx.Err()
+		default:
+		}
+	}
+
 	if stage.leftStage != nil {
-		left, err = e.evaluateStage(stage.leftStage, parameters)
+		left, err = e.evaluateStage(ctx, stage.leftStage, parameters)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// the expression on the right is evaluated once for each element on the left.
+	if stage.symbol == QUANTIFY {
+		return quantify(stage.name, left, parameters, func(item Parameters) (interface{}, error) {
+			return e.evaluateStage(ctx, stage.rightStage, item)
+		})
+	}
+
 	if stage.isShortCircuitable() {
 		switch stage.symbol {
 		case AND:
@@ -217,37 +273,38 @@ func (e EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Pa
 	}
 
 	if right != shortCircuitHolder && stage.rightStage != nil {
-		right, err = e.evaluateStage(stage.rightStage, parameters)
+		right, err = e.evaluateStage(ctx, stage.rightStage, parameters)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if e.ChecksTypes {
-		if stage.typeCheck == nil {
 
-			err = typeCheck(stage.leftTypeCheck, left, stage.symbol, stage.typeErrorFormat)
-			if err != nil {
-				return nil, err
-			}
+		err = typeCheck(stage.leftTypeCheck, left, left, right, stage.symbol, stage.typeErrorFormat)
+		if err != nil {
+			return nil, err
+		}
 
-			err = typeCheck(stage.rightTypeCheck, right, stage.symbol, stage.typeErrorFormat)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			// special case where the type check needs to know both sides to determine if the operator can handle it
-			if !stage.typeCheck(left, right) {
-				errorMsg := fmt.Sprintf(stage.typeErrorFormat, left, stage.symbol.String())
-				return nil, errors.New(errorMsg)
-			}
+		err = typeCheck(stage.rightTypeCheck, right, left, right, stage.symbol, stage.typeErrorFormat)
+		if err != nil {
+			return nil, err
+		}
+
+		// special case where the type check needs to know both sides to determine if the operator can handle it
+		if stage.typeCheck != nil && !stage.typeCheck(left, right) {
+			return nil, newTypeMismatchError(stage.symbol, stage.typeErrorFormat, left, left, right)
 		}
 	}
 
 	return stage.operator(left, right, parameters)
 }
 
-func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, format string) error {
+/*
+Checks the given [value] (which is one of [left] or [right]) with the given [check].
+Returns a TypeMismatchError describing both sides of the operator if the check fails.
+*/
+func typeCheck(check stageTypeCheck, value interface{}, left interface{}, right interface{}, symbol OperatorSymbol, format string) error {
 
 	if check == nil {
 		return nil
@@ -257,8 +314,7 @@ func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, f
 		return nil
 	}
 
-	errorMsg := fmt.Sprintf(format, value, symbol.String())
-	return errors.New(errorMsg)
+	return newTypeMismatchError(symbol, format, value, left, right)
 }
 
 /*
@@ -295,4 +351,5 @@ Removes the tokens from the EvaluableExpression. This will cause the Tokens() an
 */
 func (e *EvaluableExpression) CleanupTokens() {
 	e.tokens = e.tokens[:0]
+	e.positions = nil
 }
@@ -0,0 +1,237 @@
+package govaluate
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// the kind of query that `ToElasticsearchQuery` writes, for errors.
+const elasticsearchTarget = "Elasticsearch queries"
+
+/*
+Returns an Elasticsearch (or OpenSearch) query representing this expression, such as `{"range": {"age": {"gt": 30}}}` for `age > 30`.
+The query is made of `map[string]interface{}`s and `[]interface{}`s, so it can be given to `json.Marshal`,
+usually as the "query" of a search request.
+
+Parameters and accessors are fields, with accessors written in dot notation, as in `address.city`.
+Each comparison must be between a field and a literal; `&&`, `||` and `!` are the `must`, `should` and `must_not` of `bool` queries,
+`==` is `term`, `in` is `terms`, `>` (and the like) are `range`, and `=~` is `regexp`. A field on its own is a `term` of true.
+`== nil` matches documents without the field, and `!= nil` those with it, using `exists`.
+
+Strings are matched exactly by `term`, `terms` and `regexp`, so they're usually compared with keyword fields.
+Patterns are given as they were written, though Elasticsearch always matches the whole of a value, and only supports some of Go's syntax.
+Times are given as `time.Time`s, which `json.Marshal` writes in RFC 3339, rather than being formatted with `QueryDateFormat`.
+Anything without an equivalent query, such as arithmetic, ternaries, functions or comparisons between two fields, is an error.
+*/
+func (e EvaluableExpression) ToElasticsearchQuery() (map[string]interface{}, error) {
+
+	stage, err := e.queryStages()
+	if err != nil {
+		return nil, err
+	}
+
+	if stage == nil {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}, nil
+	}
+	return elasticsearchQuery(stage)
+}
+
+/*
+Returns the query which matches the documents that [stage] is true for.
+*/
+func elasticsearchQuery(stage *evaluationStage) (map[string]interface{}, error) {
+
+	switch stage.symbol {
+
+	case NOOP:
+		return elasticsearchQuery(stage.rightStage)
+
+	case AND, OR:
+		occurrence := "must"
+		if stage.symbol == OR {
+			occurrence = "should"
+		}
+
+		var clauses []interface{}
+		for _, side := range []*evaluationStage{stage.leftStage, stage.rightStage} {
+
+			query, err := elasticsearchQuery(side)
+			if err != nil {
+				return nil, err
+			}
+
+			// chains of the same operator are written as one list, rather than nested pairs.
+			if nested, ok := elasticsearchClauses(query, occurrence); ok {
+				clauses = append(clauses, nested...)
+				continue
+			}
+			clauses = append(clauses, query)
+		}
+		return elasticsearchBool(occurrence, clauses), nil
+
+	case INVERT:
+		query, err := elasticsearchQuery(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		return elasticsearchNegate(query), nil
+
+	case VALUE, ACCESS:
+		field, err := queryField(stage, elasticsearchTarget)
+		if err != nil {
+			return nil, err
+		}
+		return elasticsearchLeaf("term", field, true), nil
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+
+		switch value {
+		case true:
+			return map[string]interface{}{"match_all": map[string]interface{}{}}, nil
+		case false:
+			return map[string]interface{}{"match_none": map[string]interface{}{}}, nil
+		}
+		return nil, fmt.Errorf("the value '%v' is not a condition, so can't be written as an Elasticsearch query", value)
+
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ:
+		return elasticsearchComparison(stage)
+
+	case IN:
+		field, err := queryField(stage.leftStage, elasticsearchTarget)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := queryValues(stage.rightStage, elasticsearchTarget)
+		if err != nil {
+			return nil, err
+		}
+		return elasticsearchLeaf("terms", field, queryLiteral(values)), nil
+	}
+
+	return nil, fmt.Errorf("%s is unsupported in %s", queryConstruct(stage), elasticsearchTarget)
+}
+
+/*
+Returns the query for a comparison between a field and a literal, which may be given in either order.
+*/
+func elasticsearchComparison(stage *evaluationStage) (map[string]interface{}, error) {
+
+	symbol, fieldStage, valueStage := queryComparison(stage)
+
+	field, err := queryField(fieldStage, elasticsearchTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := queryValue(valueStage, elasticsearchTarget)
+	if err != nil {
+		return nil, err
+	}
+	value = queryLiteral(value)
+
+	switch symbol {
+
+	case EQ, NEQ:
+		var query map[string]interface{}
+
+		// nil is compared by whether the field exists, so `== nil` is the query which is negated.
+		if value == nil {
+			query = map[string]interface{}{"exists": map[string]interface{}{"field": field}}
+			if symbol == EQ {
+				query = elasticsearchNegate(query)
+			}
+			return query, nil
+		}
+
+		query = elasticsearchLeaf("term", field, value)
+		if symbol == NEQ {
+			query = elasticsearchNegate(query)
+		}
+		return query, nil
+
+	case GT:
+		return elasticsearchLeaf("range", field, map[string]interface{}{"gt": value}), nil
+	case LT:
+		return elasticsearchLeaf("range", field, map[string]interface{}{"lt": value}), nil
+	case GTE:
+		return elasticsearchLeaf("range", field, map[string]interface{}{"gte": value}), nil
+	case LTE:
+		return elasticsearchLeaf("range", field, map[string]interface{}{"lte": value}), nil
+	}
+
+	var pattern string
+
+	switch value := value.(type) {
+	case string:
+		pattern = value
+	case *regexp.Regexp:
+		pattern = value.String()
+	default:
+		return nil, fmt.Errorf("the pattern '%v' is not a string, so can't be written as an Elasticsearch query", value)
+	}
+
+	query := elasticsearchLeaf("regexp", field, pattern)
+	if symbol == NREQ {
+		query = elasticsearchNegate(query)
+	}
+	return query, nil
+}
+
+/*
+Returns the query which matches the documents that [query] doesn't.
+*/
+func elasticsearchNegate(query map[string]interface{}) map[string]interface{} {
+
+	// negating a negation gives back what was negated.
+	if clauses, ok := elasticsearchClauses(query, "must_not"); ok && len(clauses) == 1 {
+		if inner, ok := clauses[0].(map[string]interface{}); ok {
+			return inner
+		}
+	}
+
+	return elasticsearchBool("must_not", []interface{}{query})
+}
+
+/*
+Returns a `bool` query whose [clauses] occur as the given [occurrence], such as "must".
+Queries which should occur need at least one of their clauses to match, as they're the only clauses of their query.
+*/
+func elasticsearchBool(occurrence string, clauses []interface{}) map[string]interface{} {
+
+	body := map[string]interface{}{occurrence: clauses}
+	if occurrence == "should" {
+		body["minimum_should_match"] = 1
+	}
+	return map[string]interface{}{"bool": body}
+}
+
+/*
+Returns the clauses of [query], if it's a `bool` query made by `elasticsearchBool` with the given [occurrence].
+*/
+func elasticsearchClauses(query map[string]interface{}, occurrence string) ([]interface{}, bool) {
+
+	body, ok := query["bool"].(map[string]interface{})
+	if !ok || len(query) != 1 {
+		return nil, false
+	}
+
+	clauses, ok := body[occurrence].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	expected := 1
+	if occurrence == "should" {
+		expected = 2
+	}
+	return clauses, len(body) == expected
+}
+
+/*
+Returns a query of the given [kind], such as "term", on a single [field].
+*/
+func elasticsearchLeaf(kind string, field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{kind: map[string]interface{}{field: value}}
+}
@@ -0,0 +1,213 @@
+package govaluate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the kind of query that `ToMongoQuery` writes, for errors.
+const mongoTarget = "MongoDB queries"
+
+/*
+Returns a MongoDB filter document representing this expression, such as `{"age": {"$gt": 30}}` for `age > 30`.
+The document is made of `map[string]interface{}`s and `[]interface{}`s, which is the shape of `bson.M` and `bson.A`,
+so it can be given to the driver's `Find` as it is.
+
+Parameters and accessors are fields, with accessors written in dot notation, as in `address.city`.
+Field names which start with `$` (or contain NUL) are errors, since they'd be taken as operators.
+Each comparison must be between a field and a literal; `&&` and `||` are `$and` and `$or`, `in` is `$in`, and `=~` is `$regex`.
+A field on its own is compared with true, and `!` is written as `$not` where it can be, otherwise `$nor`.
+`!= nil` is `$exists` (and not null); `== nil` also matches documents without the field, as missing parameters would.
+
+Times are given as `time.Time`s, which the driver writes as dates, rather than being formatted with `QueryDateFormat`.
+Anything without an equivalent filter, such as arithmetic, ternaries, functions or comparisons between two fields, is an error.
+*/
+func (e EvaluableExpression) ToMongoQuery() (map[string]interface{}, error) {
+
+	stage, err := e.queryStages()
+	if err != nil {
+		return nil, err
+	}
+
+	if stage == nil {
+		return map[string]interface{}{}, nil
+	}
+	return mongoFilter(stage)
+}
+
+/*
+Returns the filter which matches the documents that [stage] is true for.
+*/
+func mongoFilter(stage *evaluationStage) (map[string]interface{}, error) {
+
+	switch stage.symbol {
+
+	case NOOP:
+		return mongoFilter(stage.rightStage)
+
+	case AND, OR:
+		operator := "$and"
+		if stage.symbol == OR {
+			operator = "$or"
+		}
+
+		var clauses []interface{}
+		for _, side := range []*evaluationStage{stage.leftStage, stage.rightStage} {
+
+			filter, err := mongoFilter(side)
+			if err != nil {
+				return nil, err
+			}
+
+			// chains of the same operator are written as one list, rather than nested pairs.
+			if nested, ok := filter[operator].([]interface{}); ok && len(filter) == 1 {
+				clauses = append(clauses, nested...)
+				continue
+			}
+			clauses = append(clauses, filter)
+		}
+		return map[string]interface{}{operator: clauses}, nil
+
+	case INVERT:
+		filter, err := mongoFilter(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		return mongoNegate(filter), nil
+
+	case VALUE, ACCESS:
+		field, err := queryField(stage, mongoTarget)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{field: true}, nil
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+
+		switch value {
+		case true:
+			return map[string]interface{}{}, nil
+		case false:
+			return mongoNegate(map[string]interface{}{}), nil
+		}
+		return nil, fmt.Errorf("the value '%v' is not a condition, so can't be written as a MongoDB filter", value)
+
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ:
+		return mongoComparison(stage)
+
+	case IN:
+		field, err := queryField(stage.leftStage, mongoTarget)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := queryValues(stage.rightStage, mongoTarget)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{field: map[string]interface{}{"$in": queryLiteral(values)}}, nil
+	}
+
+	return nil, fmt.Errorf("%s is unsupported in %s", queryConstruct(stage), mongoTarget)
+}
+
+/*
+Returns the filter for a comparison between a field and a literal, which may be given in either order.
+*/
+func mongoComparison(stage *evaluationStage) (map[string]interface{}, error) {
+
+	symbol, fieldStage, valueStage := queryComparison(stage)
+
+	field, err := queryField(fieldStage, mongoTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := queryValue(valueStage, mongoTarget)
+	if err != nil {
+		return nil, err
+	}
+	value = queryLiteral(value)
+
+	var condition interface{}
+
+	switch symbol {
+
+	case EQ:
+		condition = value
+
+	case NEQ:
+		if value == nil {
+			condition = map[string]interface{}{"$exists": true, "$ne": nil}
+		} else {
+			condition = map[string]interface{}{"$ne": value}
+		}
+
+	case GT:
+		condition = map[string]interface{}{"$gt": value}
+	case LT:
+		condition = map[string]interface{}{"$lt": value}
+	case GTE:
+		condition = map[string]interface{}{"$gte": value}
+	case LTE:
+		condition = map[string]interface{}{"$lte": value}
+
+	case REQ, NREQ:
+		var pattern string
+
+		switch value := value.(type) {
+		case string:
+			pattern = value
+		case *regexp.Regexp:
+			pattern = value.String()
+		default:
+			return nil, fmt.Errorf("the pattern '%v' is not a string, so can't be written as a MongoDB filter", value)
+		}
+
+		condition = map[string]interface{}{"$regex": pattern}
+		if symbol == NREQ {
+			condition = map[string]interface{}{"$not": condition}
+		}
+	}
+
+	return map[string]interface{}{field: condition}, nil
+}
+
+/*
+Returns the filter which matches the documents that [filter] doesn't.
+*/
+func mongoNegate(filter map[string]interface{}) map[string]interface{} {
+
+	if len(filter) == 1 {
+		for key, value := range filter {
+
+			// negating a negation gives back what was negated.
+			if key == "$nor" {
+				if clauses, ok := value.([]interface{}); ok && len(clauses) == 1 {
+					if inner, ok := clauses[0].(map[string]interface{}); ok {
+						return inner
+					}
+				}
+			}
+
+			if strings.HasPrefix(key, "$") {
+				break
+			}
+
+			// conditions on a single field are negated on that field, as `$not` can't be used on its own.
+			operators, ok := value.(map[string]interface{})
+			if !ok {
+				return map[string]interface{}{key: map[string]interface{}{"$ne": value}}
+			}
+
+			if inner, ok := operators["$not"]; ok && len(operators) == 1 {
+				return map[string]interface{}{key: inner}
+			}
+			return map[string]interface{}{key: map[string]interface{}{"$not": operators}}
+		}
+	}
+
+	return map[string]interface{}{"$nor": []interface{}{filter}}
+}
@@ -0,0 +1,436 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+/*
+Evaluates as much of this expression as possible using only the [known] parameters,
+and returns the remaining (residual) expression, which only refers to parameters that weren't known.
+Evaluating the residual expression with the rest of the parameters gives the same result as evaluating this expression with all of them.
+
+Known parameters are substituted with their values, sub-expressions which no longer refer to any parameters are replaced by their results,
+and `&&`, `||`, `??` and ternaries are short-circuited wherever their known operands decide the result.
+For instance, with `a` known to be 5, `a > 3 && b == c` becomes `b == c`, and `a > 10 && b == c` becomes `false`.
+Accessors on known parameters are evaluated, but functions are only run if their `FunctionDefinition` says they're pure,
+since other functions may depend on when (or how often) they're called.
+
+Any parameter that [known] returns an error for is considered unknown. Errors from evaluating known parts of the expression,
+such as type mismatches, are returned.
+The residual expression keeps this expression's options, and its `String()` is the residual's formatted source text (see `Format`).
+Known values are kept in the residual exactly as they were given. If the residual can't be written as source text,
+such as when it keeps a known duration, map, or slice of a type other than `[]interface{}`, it has no source text or tokens,
+as if `CleanupTokens` had been called, though it's evaluated (and can be partially evaluated again) all the same.
+*/
+func (e EvaluableExpression) PartialEval(known Parameters) (*EvaluableExpression, error) {
+
+	if known == nil {
+		known = DUMMY_PARAMETERS
+	}
+
+	stage, err := e.syntaxTree()
+	if err != nil {
+		return nil, err
+	}
+
+	if stage == nil {
+		return &e, nil
+	}
+
+	evaluator := partialEvaluator{
+		expression: e,
+		parameters: &sanitizedParameters{
+			orig:     known,
+			ctx:      context.Background(),
+			numerics: e.options.Numerics,
+			fields:   fieldNamingFor(e.options),
+			location: e.options.Location,
+		},
+	}
+
+	residual, err := evaluator.evaluate(stage)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &expressionWriter{
+		functions: e.functionsByName(),
+	}
+
+	ret := &EvaluableExpression{
+		QueryDateFormat:  e.QueryDateFormat,
+		ChecksTypes:      e.ChecksTypes,
+		evaluationStages: residual,
+		options:          e.options,
+	}
+
+	if residual == nil {
+		return ret, nil
+	}
+
+	// known values without a literal form (such as durations or maps) can't be written, but are kept in the residual's stages all the same.
+	err = writer.writeNode(nodeFromStage(residual), false)
+	if err != nil {
+		return ret, nil
+	}
+
+	written, err := writer.expression(e.options)
+	if err != nil {
+		return nil, err
+	}
+
+	// the residual is evaluated with the values it was given, rather than those parsed from its text, which may be less precise.
+	ret.inputExpression = written.inputExpression
+	ret.tokens = written.tokens
+	ret.positions = written.positions
+	return ret, nil
+}
+
+/*
+Returns every function this expression calls, by the name it calls them.
+*/
+func (e EvaluableExpression) functionsByName() map[string]interface{} {
+
+	ret := make(map[string]interface{})
+
+	for i, token := range e.tokens {
+
+		if token.Kind != FUNCTION {
+			continue
+		}
+
+		name := e.positions.text(i)
+		if name != "" {
+			ret[name] = token.Value
+		}
+	}
+	return ret
+}
+
+// returned when the expression of a quantifier can't be evaluated for one of its elements.
+var errUnknownItem = errors.New("unknown element")
+
+type partialEvaluator struct {
+	expression EvaluableExpression
+	parameters *sanitizedParameters
+}
+
+/*
+Returns a copy of [stage] with as much of it evaluated as possible.
+Stages which are shared with the original expression are never modified.
+*/
+func (p partialEvaluator) evaluate(stage *evaluationStage) (*evaluationStage, error) {
+
+	var left, right *evaluationStage
+	var err error
+
+	if stage == nil {
+		return nil, nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		return stage, nil
+
+	case VALUE:
+		value, err := p.parameters.Get(stage.name)
+		if err != nil {
+			return stage, nil
+		}
+		return newLiteralStage(value), nil
+
+	case ACCESS:
+		right, err = p.evaluate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+
+		ret := stage.withChildren(nil, right)
+
+		_, err = p.parameters.Get(stage.path[0])
+		if err != nil || !isKnownStage(right) {
+			return ret, nil
+		}
+		return p.fold(ret)
+
+	case FUNCTIONAL:
+		right, err = p.evaluate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		ret := stage.withChildren(nil, right)
+
+		if p.expression.options.Functions[stage.name].Pure && isKnownStage(right) {
+			return p.fold(ret)
+		}
+		return ret, nil
+
+	case ITEM_VALUE:
+		if p.parameters.hasItem {
+			return newLiteralStage(p.parameters.item), nil
+		}
+		return stage, nil
+
+	case QUANTIFY:
+		return p.quantifier(stage)
+
+	case INDEX_ACCESS:
+		left, err = p.evaluate(stage.leftStage)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err = p.evaluate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+
+		if isKnownStage(left) && isKnownStage(right) {
+			return p.fold(stage.withChildren(left, right))
+		}
+		return stage.withChildren(left, right), nil
+	}
+
+	left, err = p.evaluate(stage.leftStage)
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit the same way evaluation does, before looking at the right side at all.
+	if left != nil && left.symbol == LITERAL {
+
+		value, _ := left.operator(nil, nil, nil)
+
+		switch stage.symbol {
+		case AND:
+			if value == false {
+				return left, nil
+			}
+		case OR:
+			if value == true {
+				return left, nil
+			}
+		case COALESCE:
+			fallthrough
+		case TERNARY_FALSE:
+			if value != nil {
+				return left, nil
+			}
+		case TERNARY_TRUE:
+			if value == false {
+				return newLiteralStage(nil), nil
+			}
+		}
+	}
+
+	right, err = p.evaluate(stage.rightStage)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := stage.withChildren(left, right)
+
+	// the elements of a comma-separated list are only evaluated together, by whatever the list is given to.
+	// likewise, empty parenthesis (of a call without arguments) have no value of their own.
+	if stage.symbol == SEPARATE || isEmptyClause(stage) {
+		return ret, nil
+	}
+
+	if isKnownStage(left) && isKnownStage(right) {
+		return p.fold(ret)
+	}
+
+	return simplifyStage(ret), nil
+}
+
+/*
+Evaluates a quantifier whose array is known, if its expression can be evaluated for every element.
+Otherwise, returns it with as much of its array and expression evaluated as possible, where `#` is unknown.
+*/
+func (p partialEvaluator) quantifier(stage *evaluationStage) (*evaluationStage, error) {
+
+	left, err := p.evaluate(stage.leftStage)
+	if err != nil {
+		return nil, err
+	}
+
+	if isKnownStage(left) {
+
+		collection, _ := left.operator(nil, nil, nil)
+
+		value, err := quantify(stage.name, collection, p.parameters, func(item Parameters) (interface{}, error) {
+
+			bound := partialEvaluator{expression: p.expression, parameters: item.(*sanitizedParameters)}
+
+			result, err := bound.evaluate(stage.rightStage)
+			if err != nil {
+				return nil, err
+			}
+			if result.symbol != LITERAL {
+				return nil, errUnknownItem
+			}
+			return result.operator(nil, nil, nil)
+		})
+
+		if err == nil {
+			return newLiteralStage(value), nil
+		}
+		if err != errUnknownItem {
+			return nil, err
+		}
+	}
+
+	unbound := partialEvaluator{expression: p.expression, parameters: withoutItem(p.parameters)}
+
+	right, err := unbound.evaluate(stage.rightStage)
+	if err != nil {
+		return nil, err
+	}
+	return stage.withChildren(left, right), nil
+}
+
+/*
+Evaluates [stage], all of whose children are literals, and returns a literal of the result.
+*/
+func (p partialEvaluator) fold(stage *evaluationStage) (*evaluationStage, error) {
+
+	value, err := p.expression.evaluateStage(context.Background(), stage, p.parameters)
+	if err != nil {
+		return nil, err
+	}
+	return newLiteralStage(value), nil
+}
+
+/*
+Simplifies a stage which has one known (literal) side and one unknown side, where the known side decides the result
+or makes the operator redundant.
+*/
+func simplifyStage(stage *evaluationStage) *evaluationStage {
+
+	var leftValue, rightValue interface{}
+
+	leftKnown := stage.leftStage != nil && stage.leftStage.symbol == LITERAL
+	rightKnown := stage.rightStage != nil && stage.rightStage.symbol == LITERAL
+
+	if leftKnown {
+		leftValue, _ = stage.leftStage.operator(nil, nil, nil)
+	}
+	if rightKnown {
+		rightValue, _ = stage.rightStage.operator(nil, nil, nil)
+	}
+
+	switch stage.symbol {
+
+	// the unknown side of `&&` and `||` must still be a bool, so it can only be relied on to be one (or dropped) if it's always a bool.
+	case AND:
+		if leftKnown && leftValue == true && isBoolStage(stage.rightStage) {
+			return stage.rightStage
+		}
+		if rightKnown && rightValue == true && isBoolStage(stage.leftStage) {
+			return stage.leftStage
+		}
+		if rightKnown && rightValue == false && isBoolStage(stage.leftStage) {
+			return stage.rightStage
+		}
+
+	case OR:
+		if leftKnown && leftValue == false && isBoolStage(stage.rightStage) {
+			return stage.rightStage
+		}
+		if rightKnown && rightValue == false && isBoolStage(stage.leftStage) {
+			return stage.leftStage
+		}
+		if rightKnown && rightValue == true && isBoolStage(stage.leftStage) {
+			return stage.rightStage
+		}
+
+	case TERNARY_TRUE:
+		if leftKnown && leftValue == true {
+			return stage.rightStage
+		}
+
+	case TERNARY_FALSE:
+		if leftKnown {
+			return stage.rightStage
+		}
+
+		// without its `?`, a `:` is the same as `??`.
+		if stage.leftStage.symbol != TERNARY_TRUE {
+			stage.symbol = COALESCE
+		}
+
+	case COALESCE:
+		if leftKnown {
+			return stage.rightStage
+		}
+
+	case IN:
+		// nothing is in an empty array, and an empty array can't be written in an expression.
+		if rightKnown && reflect.ValueOf(rightValue).Kind() == reflect.Slice && reflect.ValueOf(rightValue).Len() == 0 {
+			return newLiteralStage(false)
+		}
+	}
+
+	return stage
+}
+
+/*
+Returns true if [stage] is missing, a literal, empty parenthesis, or a comma-separated list of only literals.
+*/
+func isKnownStage(stage *evaluationStage) bool {
+
+	if stage == nil || stage.symbol == LITERAL || isEmptyClause(stage) {
+		return true
+	}
+	return stage.symbol == SEPARATE && isKnownStage(stage.leftStage) && isKnownStage(stage.rightStage)
+}
+
+/*
+Returns true if [stage] always results in a bool (or an error), whatever its operands are, such as a comparison.
+*/
+func isBoolStage(stage *evaluationStage) bool {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if stage == nil {
+		return false
+	}
+
+	switch stage.symbol {
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN, AND, OR, INVERT:
+		return true
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		_, isBool := value.(bool)
+		return isBool
+	}
+	return false
+}
+
+func isEmptyClause(stage *evaluationStage) bool {
+	return stage.symbol == NOOP && stage.leftStage == nil && stage.rightStage == nil
+}
+
+func newLiteralStage(value interface{}) *evaluationStage {
+
+	return &evaluationStage{
+		symbol:   LITERAL,
+		operator: makeLiteralStage(value),
+	}
+}
+
+/*
+Returns a copy of this stage with the given children.
+*/
+func (e *evaluationStage) withChildren(left *evaluationStage, right *evaluationStage) *evaluationStage {
+
+	ret := *e
+	ret.leftStage = left
+	ret.rightStage = right
+	return &ret
+}
@@ -1,12 +1,66 @@
 package govaluate
 
 import (
-	"errors"
+	"database/sql"
 	"fmt"
+	"math/big"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+/*
+Represents how the placeholders for arguments are written in the queries made by `ToSQLQueryWithArgs`.
+*/
+type SQLPlaceholderStyle int
+
+const (
+
+	// Placeholders are `?`, as used by MySQL and SQLite.
+	QUESTION_PLACEHOLDERS SQLPlaceholderStyle = iota
+
+	// Placeholders are numbered from `$1`, as used by PostgreSQL.
+	DOLLAR_PLACEHOLDERS
+
+	// Placeholders are named, from `:p1`, as used by Oracle. Their arguments are given as `sql.NamedArg`s of the same names.
+	NAMED_PLACEHOLDERS
+
+	// Placeholders are numbered from `@p1`, as used by SQL Server.
+	AT_PLACEHOLDERS
+)
+
+/*
+The arguments of a query being made by `ToSQLQueryWithArgs`, in the order their placeholders were written.
+*/
+type sqlArguments struct {
+	style  SQLPlaceholderStyle
+	values []interface{}
+}
+
+/*
+Adds the given argument [value], and returns the placeholder for it.
+*/
+func (a *sqlArguments) add(value interface{}) string {
+
+	number := strconv.Itoa(len(a.values) + 1)
+
+	switch a.style {
+	case DOLLAR_PLACEHOLDERS:
+		a.values = append(a.values, value)
+		return "$" + number
+	case NAMED_PLACEHOLDERS:
+		a.values = append(a.values, sql.Named("p"+number, value))
+		return ":p" + number
+	case AT_PLACEHOLDERS:
+		a.values = append(a.values, value)
+		return "@p" + number
+	}
+
+	a.values = append(a.values, value)
+	return "?"
+}
+
 /*
 Returns a string representing this expression as if it were written in SQL.
 This function assumes that all parameters exist within the same table, and that the table essentially represents
@@ -15,153 +69,491 @@ If your data model is more normalized, you may need to consider iterating throug
 to create your query.
 
 Boolean values are considered to be "1" for true, "0" for false.
+Nil is NULL, and is compared with "IS" and "IS NOT" rather than "=" and "<>".
+Accessors such as `orders.total` are written as qualified columns, as in `[orders].[total]`.
+Ternaries are written as `CASE WHEN ... THEN ... ELSE ... END`, and `in` as `IN (...)`.
+Functions can only be written if they're given in the dialect's `Functions`, which `ToSQLQuery`'s are not.
 
 Times are formatted according to this.QueryDateFormat.
+Strings (and patterns and times) are quoted, with any quotes within them doubled.
+
+This writes the `DefaultSQLDialect`; use `ToSQLQueryFor` to write a query for a particular database.
 */
 func (e EvaluableExpression) ToSQLQuery() (string, error) {
 
-	var stream *tokenStream
-	var transactions *expressionOutputStream
-	var transaction string
-	var err error
+	return e.toSQLQuery(DefaultSQLDialect, nil)
+}
+
+/*
+Same as `ToSQLQuery`, except that the query is written in the given [dialect], such as `PostgreSQLDialect`,
+which decides how columns are quoted, and how booleans, patterns, exponents, remainders and times are written.
+*/
+func (e EvaluableExpression) ToSQLQueryFor(dialect SQLDialect) (string, error) {
+
+	return e.toSQLQuery(dialect, nil)
+}
+
+/*
+Same as `ToSQLQuery`, except that strings, patterns and times are replaced by placeholders in the given [style],
+and returned as arguments in the order their placeholders appear, so that the query can be given to `database/sql` along with them.
+Times are given as `time.Time`s, and patterns as the strings they were written as.
+Numbers, booleans and nil are still written into the query.
+*/
+func (e EvaluableExpression) ToSQLQueryWithArgs(style SQLPlaceholderStyle) (string, []interface{}, error) {
+
+	return e.toSQLQueryWithArgs(DefaultSQLDialect, style)
+}
+
+/*
+Same as `ToSQLQueryWithArgs`, except that the query is written in the given [dialect], with the dialect's placeholders.
+*/
+func (e EvaluableExpression) ToSQLQueryWithArgsFor(dialect SQLDialect) (string, []interface{}, error) {
+
+	return e.toSQLQueryWithArgs(dialect, dialect.Placeholders)
+}
+
+func (e EvaluableExpression) toSQLQueryWithArgs(dialect SQLDialect, style SQLPlaceholderStyle) (string, []interface{}, error) {
+
+	arguments := &sqlArguments{style: style}
+
+	query, err := e.toSQLQuery(dialect, arguments)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, arguments.values, nil
+}
+
+/*
+Describes the query being written by `toSQLQuery`.
+*/
+type sqlWriter struct {
+	dialect SQLDialect
+
+	// the layout times are written with.
+	dateFormat string
+
+	// if given, literals which would be quoted are added to these instead.
+	arguments *sqlArguments
+}
+
+/*
+Writes this expression as SQL in the given [dialect]. If [arguments] are given, literals which would be quoted are added to them instead.
+*/
+func (e EvaluableExpression) toSQLQuery(dialect SQLDialect, arguments *sqlArguments) (string, error) {
+
+	stage, err := e.queryStages()
+	if err != nil {
+		return "", err
+	}
+
+	writer := &sqlWriter{
+		dialect:    dialect,
+		dateFormat: dialect.DateFormat,
+		arguments:  arguments,
+	}
+
+	if writer.dateFormat == "" {
+		writer.dateFormat = e.QueryDateFormat
+	}
 
-	stream = newTokenStream(e.tokens)
-	transactions = new(expressionOutputStream)
+	return writer.write(stage)
+}
+
+/*
+Returns the SQL for [stage] and everything beneath it.
+*/
+func (w *sqlWriter) write(stage *evaluationStage) (string, error) {
+
+	if stage == nil {
+		return "", nil
+	}
 
-	for stream.hasNext() {
+	switch stage.symbol {
 
-		transaction, err = e.findNextSQLString(stream, transactions)
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return w.value(value)
+
+	case VALUE:
+		return w.dialect.quoteIdentifier(stage.name), nil
+
+	case ACCESS:
+		if stage.rightStage != nil {
+			return "", fmt.Errorf("the method call '%s' is unsupported in %s output", strings.Join(stage.path, "."), w.dialect.Name)
+		}
+
+		// accessors are columns qualified by the names before them, such as a table's.
+		names := make([]string, len(stage.path))
+		for i, name := range stage.path {
+			names[i] = w.dialect.quoteIdentifier(name)
+		}
+		return strings.Join(names, "."), nil
+
+	case NOOP:
+		inner, err := w.write(stage.rightStage)
 		if err != nil {
 			return "", err
 		}
+		return "( " + inner + " )", nil
 
-		transactions.add(transaction)
+	case FUNCTIONAL:
+		return w.function(stage)
+
+	case IN:
+		return w.in(stage)
+
+	case TERNARY_TRUE, TERNARY_FALSE:
+		return w.ternary(stage)
+
+	case LIST_LITERAL:
+		elements, err := w.elements(stage)
+		if err != nil {
+			return "", err
+		}
+		return "(" + strings.Join(elements, ", ") + ")", nil
+
+	case NEGATE, INVERT, BITWISE_NOT:
+		operand, err := w.write(stage.rightStage)
+		if err != nil {
+			return "", err
+		}
+
+		switch stage.symbol {
+		case INVERT:
+			return "NOT " + operand, nil
+		case BITWISE_NOT:
+			return "~" + operand, nil
+		}
+		return "-" + operand, nil
+
+	case QUANTIFY, ITEM_VALUE:
+		return "", fmt.Errorf("quantifiers are unsupported in %s output", w.dialect.Name)
+
+	case INDEX_ACCESS:
+		return "", fmt.Errorf("indexes are unsupported in %s output", w.dialect.Name)
 	}
 
-	return transactions.createString(" "), nil
-}
+	left, err := w.write(stage.leftStage)
+	if err != nil {
+		return "", err
+	}
 
-func (e EvaluableExpression) findNextSQLString(stream *tokenStream, transactions *expressionOutputStream) (string, error) {
+	right, err := w.write(stage.rightStage)
+	if err != nil {
+		return "", err
+	}
 
-	var token ExpressionToken
-	var ret string
+	switch stage.symbol {
 
-	token = stream.next()
+	case EQ, NEQ:
+		// SQL has no array values, a list could only be compared with a row of the same length.
+		if isListStage(stage.leftStage) || isListStage(stage.rightStage) {
+			return "", fmt.Errorf("comparing a list with '%s' is unsupported in %s output, use 'in' instead", stage.symbol, w.dialect.Name)
+		}
 
-	switch token.Kind {
+		// nil is compared with IS rather than =, and is written on the right whichever side it's given on.
+		if isNilStage(stage.leftStage) {
+			left, right = right, left
+		}
 
-	case STRING:
-		ret = fmt.Sprintf("'%v'", token.Value)
-	case PATTERN:
-		ret = fmt.Sprintf("'%s'", token.Value.(*regexp.Regexp).String())
-	case TIME:
-		ret = fmt.Sprintf("'%s'", token.Value.(time.Time).Format(e.QueryDateFormat))
+		if isNilStage(stage.leftStage) || isNilStage(stage.rightStage) {
+			if stage.symbol == EQ {
+				return left + " IS " + right, nil
+			}
+			return left + " IS NOT " + right, nil
+		}
 
-	case LOGICALOP:
-		switch logicalSymbols[token.Value.(string)] {
+		if stage.symbol == EQ {
+			return left + " = " + right, nil
+		}
+		return left + " <> " + right, nil
 
-		case AND:
-			ret = "AND"
-		case OR:
-			ret = "OR"
+	case REQ, NREQ:
+		operator := w.dialect.Match
+		if stage.symbol == NREQ {
+			operator = w.dialect.NotMatch
 		}
 
-	case BOOLEAN:
-		if token.Value.(bool) {
-			ret = "1"
-		} else {
-			ret = "0"
+		if operator == "" {
+			return "", fmt.Errorf("the comparator '%s' is unsupported in %s output", stage.symbol, w.dialect.Name)
 		}
+		return left + " " + operator + " " + right, nil
 
-	case VARIABLE:
-		ret = fmt.Sprintf("[%s]", token.Value.(string))
+	case AND:
+		return left + " AND " + right, nil
+	case OR:
+		return left + " OR " + right, nil
 
-	case NUMERIC:
-		ret = fmt.Sprintf("%g", token.Value.(float64))
+	case COALESCE:
+		return fmt.Sprintf("COALESCE(%s, %s)", left, right), nil
 
-	case COMPARATOR:
-		switch comparatorSymbols[token.Value.(string)] {
+	case EXPONENT:
+		if w.dialect.Power == "" {
+			return "", fmt.Errorf("exponents are unsupported in %s output", w.dialect.Name)
+		}
+		return fmt.Sprintf("%s(%s, %s)", w.dialect.Power, left, right), nil
 
-		case EQ:
-			ret = "="
-		case NEQ:
-			ret = "<>"
-		case REQ:
-			ret = "RLIKE"
-		case NREQ:
-			ret = "NOT RLIKE"
-		default:
-			ret = fmt.Sprintf("%s", token.Value)
+	case MODULUS:
+		if w.dialect.Modulus == "" {
+			return left + " % " + right, nil
 		}
+		return fmt.Sprintf("%s(%s, %s)", w.dialect.Modulus, left, right), nil
 
-	case TERNARY:
+	case SEPARATE:
+		return left + ", " + right, nil
 
-		switch ternarySymbols[token.Value.(string)] {
+	case GT, LT, GTE, LTE, PLUS, MINUS, MULTIPLY, DIVIDE,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT:
+		return left + " " + stage.symbol.String() + " " + right, nil
+	}
 
-		case COALESCE:
+	return "", fmt.Errorf("the operator '%s' is unsupported in %s output", stage.symbol, w.dialect.Name)
+}
 
-			left := transactions.rollback()
-			right, err := e.findNextSQLString(stream, transactions)
-			if err != nil {
-				return "", err
-			}
+/*
+Returns the SQL for a literal [value].
+*/
+func (w *sqlWriter) value(value interface{}) (string, error) {
+
+	switch value := value.(type) {
 
-			ret = fmt.Sprintf("COALESCE(%v, %v)", left, right)
-		case TERNARY_TRUE:
-			fallthrough
-		case TERNARY_FALSE:
-			return "", errors.New("ternary operators are unsupported in sql output")
+	case nil:
+		return "NULL", nil
+
+	case bool:
+		if value {
+			return w.dialect.True, nil
 		}
-	case PREFIX:
-		switch prefixSymbols[token.Value.(string)] {
+		return w.dialect.False, nil
 
-		case INVERT:
-			ret = "NOT"
-		default:
+	case string:
+		return w.literal(value, value), nil
+
+	case *regexp.Regexp:
+		pattern := value.String()
+		return w.literal(pattern, pattern), nil
+
+	case time.Time:
+		return w.literal(value, value.Format(w.dateFormat)), nil
+
+	case float64:
+		return fmt.Sprintf("%g", value), nil
 
-			right, err := e.findNextSQLString(stream, transactions)
+	case *big.Rat:
+		// decimal literals, from expressions which use DECIMAL_NUMERICS.
+		return formatDecimal(value), nil
+
+	case int64, uint64:
+		// integer literals, from expressions which use INTEGER_NUMERICS.
+		return fmt.Sprintf("%d", value), nil
+
+	case []interface{}:
+		// arrays which are already known, such as those from partial evaluation.
+		elements := make([]string, len(value))
+		for i, element := range value {
+
+			text, err := w.value(element)
 			if err != nil {
 				return "", err
 			}
+			elements[i] = text
+		}
+		return "(" + strings.Join(elements, ", ") + ")", nil
+	}
+
+	return "", fmt.Errorf("the value '%v' is unsupported in %s output", value, w.dialect.Name)
+}
+
+/*
+Returns the SQL for a literal [value] which is written as the given [text]: a placeholder for it, if the query has arguments,
+otherwise the text quoted as a string.
+*/
+func (w *sqlWriter) literal(value interface{}, text string) string {
+
+	if w.arguments != nil {
+		return w.arguments.add(value)
+	}
+	return "'" + strings.Replace(text, "'", "''", -1) + "'"
+}
+
+/*
+Returns the SQL for a ternary, as a CASE. Ternaries without a `:` have no ELSE, so they're NULL when their condition is false.
+*/
+func (w *sqlWriter) ternary(stage *evaluationStage) (string, error) {
+
+	whenTrue := stage
+	var whenFalse *evaluationStage
+
+	if stage.symbol == TERNARY_FALSE {
 
-			ret = fmt.Sprintf("%s%s", token.Value.(string), right)
+		if stage.leftStage == nil || stage.leftStage.symbol != TERNARY_TRUE {
+			return "", fmt.Errorf("a ':' without a '?' is unsupported in %s output", w.dialect.Name)
 		}
-	case MODIFIER:
 
-		switch modifierSymbols[token.Value.(string)] {
+		whenTrue = stage.leftStage
+		whenFalse = stage.rightStage
+	}
 
-		case EXPONENT:
+	condition, err := w.write(whenTrue.leftStage)
+	if err != nil {
+		return "", err
+	}
 
-			left := transactions.rollback()
-			right, err := e.findNextSQLString(stream, transactions)
-			if err != nil {
-				return "", err
-			}
+	result, err := w.write(whenTrue.rightStage)
+	if err != nil {
+		return "", err
+	}
 
-			ret = fmt.Sprintf("POW(%s, %s)", left, right)
-		case MODULUS:
+	ret := "CASE WHEN " + condition + " THEN " + result
+	if whenFalse != nil {
 
-			left := transactions.rollback()
-			right, err := e.findNextSQLString(stream, transactions)
-			if err != nil {
-				return "", err
+		otherwise, err := w.write(whenFalse)
+		if err != nil {
+			return "", err
+		}
+		ret += " ELSE " + otherwise
+	}
+	return ret + " END", nil
+}
+
+/*
+Returns the SQL for `in`, whose right side is written as a list, however it was given.
+*/
+func (w *sqlWriter) in(stage *evaluationStage) (string, error) {
+
+	left, err := w.write(stage.leftStage)
+	if err != nil {
+		return "", err
+	}
+
+	right := stage.rightStage
+	for right != nil && right.symbol == NOOP {
+		right = right.rightStage
+	}
+
+	elements, err := w.elements(right)
+	if err != nil {
+		return "", err
+	}
+
+	// `IN ()` isn't valid SQL, and nothing is in an empty list.
+	if len(elements) == 0 {
+		return "1 = 0", nil
+	}
+	return left + " IN (" + strings.Join(elements, ", ") + ")", nil
+}
+
+/*
+Returns the SQL for each element of a list literal, comma-separated array, or known array, or for [stage] itself if it's none of those.
+*/
+func (w *sqlWriter) elements(stage *evaluationStage) ([]string, error) {
+
+	if stage == nil {
+		return nil, nil
+	}
+
+	switch stage.symbol {
+
+	case LIST_LITERAL:
+		return w.elements(stage.leftStage)
+
+	case SEPARATE:
+		ret, err := w.elements(stage.leftStage)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := w.write(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, right), nil
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+
+		if values, ok := value.([]interface{}); ok {
+
+			ret := make([]string, len(values))
+			for i, element := range values {
+
+				text, err := w.value(element)
+				if err != nil {
+					return nil, err
+				}
+				ret[i] = text
 			}
+			return ret, nil
+		}
+	}
+
+	element, err := w.write(stage)
+	if err != nil {
+		return nil, err
+	}
+	return []string{element}, nil
+}
 
-			ret = fmt.Sprintf("MOD(%s, %s)", left, right)
-		default:
-			ret = fmt.Sprintf("%s", token.Value)
+/*
+Returns the SQL for a call to a function, using the dialect's `Functions`.
+*/
+func (w *sqlWriter) function(stage *evaluationStage) (string, error) {
+
+	function, found := w.dialect.Functions[stage.name]
+	if !found || stage.name == "" {
+		return "", fmt.Errorf("the function '%s' has no equivalent in %s output", stage.name, w.dialect.Name)
+	}
+
+	var arguments []string
+	for _, argument := range argumentStages(stage.rightStage) {
+
+		elements, err := w.elements(argument)
+		if err != nil {
+			return "", err
 		}
-	case CLAUSE:
-		ret = "("
-	case CLAUSE_CLOSE:
-		ret = ")"
-	case SEPARATOR:
-		ret = ","
+		arguments = append(arguments, elements...)
+	}
+
+	return function(arguments)
+}
+
+/*
+Returns true if [stage] is a list literal, comma-separated array, or known array, possibly in parenthesis.
+*/
+func isListStage(stage *evaluationStage) bool {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if stage == nil {
+		return false
+	}
+
+	switch stage.symbol {
+	case LIST_LITERAL, SEPARATE:
+		return true
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		_, isArray := value.([]interface{})
+		return isArray
+	}
+	return false
+}
+
+/*
+Returns true if [stage] is the literal nil, possibly in parenthesis.
+*/
+func isNilStage(stage *evaluationStage) bool {
+
+	if !isLiteralStage(stage) {
+		return false
+	}
 
-	default:
-		errorMsg := fmt.Sprintf("Unrecognized query token '%s' of kind '%s'", token.Value, token.Kind)
-		return "", errors.New(errorMsg)
+	for stage.symbol == NOOP {
+		stage = stage.rightStage
 	}
 
-	return ret, nil
+	value, _ := stage.operator(nil, nil, nil)
+	return value == nil
 }
@@ -0,0 +1,435 @@
+package govaluate
+
+/*
+Node is a single element of an expression's syntax tree, as returned by `EvaluableExpression.AST`.
+Every Node is one of *BinaryOp, *UnaryOp, *Literal, *Variable, *Accessor, *FunctionCall, *Ternary, *Array, *List, *Index,
+*Quantifier, or *Item.
+*/
+type Node interface {
+
+	// Returns the nodes directly beneath this one, in the order they appear in the expression.
+	Children() []Node
+}
+
+/*
+An operator with two operands, such as `a + b` or `a in (1, 2)`.
+*/
+type BinaryOp struct {
+	Operator    OperatorSymbol
+	Left, Right Node
+}
+
+/*
+A prefix operator, such as `-a` or `!a`.
+*/
+type UnaryOp struct {
+	Operator OperatorSymbol
+	Operand  Node
+}
+
+/*
+A constant value: a number, string, boolean, nil, or regular expression.
+Dates are represented the same way they're evaluated, as a float64 of their unix time.
+*/
+type Literal struct {
+	Value interface{}
+}
+
+/*
+A reference to a parameter, such as `foo` or `[foo bar]`.
+*/
+type Variable struct {
+	Name string
+}
+
+/*
+A field or method of a parameter, such as `foo.Bar` or `foo.Bar(1, 2)`.
+[Path] includes the parameter's name, followed by every field or method name after it.
+[Call] is true if the last element of [Path] is called as a method, in which case [Arguments] are its arguments.
+[Optional] is nil, unless some elements of [Path] are accessed with `?.`, as in `foo?.Bar`, in which case it's true for each of them.
+*/
+type Accessor struct {
+	Path      []string
+	Call      bool
+	Arguments []Node
+	Optional  []bool
+}
+
+/*
+A call to one of the functions the expression was created with.
+[Name] is empty for expressions created by `NewEvaluableExpressionFromTokens`, since tokens don't record function names.
+*/
+type FunctionCall struct {
+	Name      string
+	Arguments []Node
+}
+
+/*
+A ternary, such as `a ? b : c`. [False] is nil if the ternary has no `:` clause, such as `a ? b`.
+*/
+type Ternary struct {
+	Condition, True, False Node
+}
+
+/*
+A comma-separated list of values, such as the right side of `a in (1, 2, 3)`.
+*/
+type Array struct {
+	Elements []Node
+}
+
+/*
+A list literal, such as `{1, 2, 3}`, which is always an array, however many elements it has.
+Arrays which are known before the expression is evaluated, such as a parameter whose value is known to `PartialEval`, are also Lists.
+*/
+type List struct {
+	Elements []Node
+}
+
+/*
+An index into an array, map, string or struct, such as `items[0]` or `headers[name]`.
+Fields which follow an index, such as the `.Name` of `items[0].Name`, are also Indexes, whose [Field] is that name
+and whose [Key] is nil.
+*/
+type Index struct {
+	Target Node
+	Key    Node
+	Field  string
+}
+
+/*
+A quantifier, such as `any(items, #.price > 10)`, which evaluates [Expression] for each element of [Collection].
+[Name] is one of "any", "all", "none", "filter" or "map".
+*/
+type Quantifier struct {
+	Name       string
+	Collection Node
+	Expression Node
+}
+
+/*
+The element a quantifier is looking at, written as `#`.
+*/
+type Item struct{}
+
+func (n *BinaryOp) Children() []Node {
+	return []Node{n.Left, n.Right}
+}
+
+func (n *UnaryOp) Children() []Node {
+	return []Node{n.Operand}
+}
+
+func (n *Literal) Children() []Node {
+	return nil
+}
+
+func (n *Variable) Children() []Node {
+	return nil
+}
+
+func (n *Accessor) Children() []Node {
+	return n.Arguments
+}
+
+func (n *FunctionCall) Children() []Node {
+	return n.Arguments
+}
+
+func (n *Ternary) Children() []Node {
+
+	if n.False == nil {
+		return []Node{n.Condition, n.True}
+	}
+	return []Node{n.Condition, n.True, n.False}
+}
+
+func (n *Array) Children() []Node {
+	return n.Elements
+}
+
+func (n *List) Children() []Node {
+	return n.Elements
+}
+
+func (n *Index) Children() []Node {
+
+	if n.Key == nil {
+		return []Node{n.Target}
+	}
+	return []Node{n.Target, n.Key}
+}
+
+func (n *Quantifier) Children() []Node {
+	return []Node{n.Collection, n.Expression}
+}
+
+func (n *Item) Children() []Node {
+	return nil
+}
+
+/*
+A Visitor's Visit method is called by `Walk` for each node it encounters.
+If the returned Visitor is not nil, `Walk` visits each of the node's children with it, followed by a call of Visit(nil).
+*/
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+/*
+Traverses the tree rooted at [node] in depth-first order, starting by calling `v.Visit(node)`.
+*/
+func Walk(v Visitor, node Node) {
+
+	if node == nil {
+		return
+	}
+
+	v = v.Visit(node)
+	if v == nil {
+		return
+	}
+
+	for _, child := range node.Children() {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+/*
+Traverses the tree rooted at [node] in depth-first order, calling [f] for each node.
+If [f] returns true, Inspect then visits each of the node's children, followed by a call of f(nil).
+*/
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+/*
+Returns the syntax tree of this expression, or nil if the expression is empty.
+The tree mirrors the expression as it was written, with the same precedence and associativity that are used to evaluate it.
+
+If `CleanupTokens` has been called, the tree is instead built from the optimized plan used to evaluate the expression,
+in which sub-expressions made only of constants (like `1 + 2`) have already been replaced by their result.
+*/
+func (e EvaluableExpression) AST() Node {
+
+	stage, err := e.syntaxTree()
+	if err != nil {
+		stage = e.evaluationStages
+	}
+
+	return nodeFromStage(stage)
+}
+
+/*
+Returns a newly-planned tree of stages which mirrors the expression exactly (see `planSyntaxTree`),
+using the same operators the expression is evaluated with, so that it can be inspected or rewritten without affecting the expression.
+If the expression's tokens have been cleaned up, its optimized plan is returned instead, and must not be modified.
+*/
+func (e EvaluableExpression) syntaxTree() (*evaluationStage, error) {
+
+	if len(e.tokens) == 0 {
+		return e.evaluationStages, nil
+	}
+
+	stage, err := planSyntaxTree(e.tokens, e.positions)
+	if err != nil {
+		return nil, err
+	}
+
+	useArrayArguments(stage, e.options.Functions)
+	useNumericMode(stage, e.options.Numerics)
+	useTimeStages(stage)
+	return stage, nil
+}
+
+func nodeFromStage(stage *evaluationStage) Node {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case VALUE:
+		return &Variable{Name: stage.name}
+
+	case LITERAL:
+		if stage.written != nil {
+			return literalNode(stage.written)
+		}
+
+		value, _ := stage.operator(nil, nil, nil)
+		return literalNode(value)
+
+	case NOOP:
+		// parenthesis, which are only needed to group the stages beneath them.
+		return nodeFromStage(stage.rightStage)
+
+	case SEPARATE:
+		ret := &Array{}
+		for ; stage.symbol == SEPARATE; stage = stage.leftStage {
+			ret.Elements = append([]Node{nodeFromStage(stage.rightStage)}, ret.Elements...)
+		}
+		ret.Elements = append([]Node{nodeFromStage(stage)}, ret.Elements...)
+		return ret
+
+	case LIST_LITERAL:
+		ret := &List{}
+
+		elements := stage.leftStage
+		if elements == nil {
+			return ret
+		}
+
+		if elements.symbol == SEPARATE {
+			ret.Elements = nodeFromStage(elements).(*Array).Elements
+		} else {
+			ret.Elements = []Node{nodeFromStage(elements)}
+		}
+		return ret
+
+	case IN:
+		// single-element arrays (like the right side of `a in (1)`) are planned as a literal which produces a slice,
+		// which is written the way it was given.
+		ret := &BinaryOp{
+			Operator: IN,
+			Left:     nodeFromStage(stage.leftStage),
+			Right:    nodeFromStage(stage.rightStage),
+		}
+
+		if list, ok := ret.Right.(*List); ok && isLiteralStage(stage.rightStage) {
+			ret.Right = &Array{Elements: list.Elements}
+		}
+		return ret
+
+	case FUNCTIONAL:
+		return &FunctionCall{
+			Name:      stage.name,
+			Arguments: argumentsFromStage(stage.rightStage),
+		}
+
+	case ACCESS:
+		return &Accessor{
+			Path:      stage.path,
+			Call:      stage.rightStage != nil,
+			Arguments: argumentsFromStage(stage.rightStage),
+			Optional:  stage.optional,
+		}
+
+	case INDEX_ACCESS:
+		if stage.name != "" {
+			return &Index{
+				Target: nodeFromStage(stage.leftStage),
+				Field:  stage.name,
+			}
+		}
+
+		return &Index{
+			Target: nodeFromStage(stage.leftStage),
+			Key:    nodeFromStage(stage.rightStage),
+		}
+
+	case QUANTIFY:
+		return &Quantifier{
+			Name:       stage.name,
+			Collection: nodeFromStage(stage.leftStage),
+			Expression: nodeFromStage(stage.rightStage),
+		}
+
+	case ITEM_VALUE:
+		return &Item{}
+
+	case NEGATE:
+		fallthrough
+	case INVERT:
+		fallthrough
+	case BITWISE_NOT:
+		return &UnaryOp{
+			Operator: stage.symbol,
+			Operand:  nodeFromStage(stage.rightStage),
+		}
+
+	case TERNARY_TRUE:
+		return &Ternary{
+			Condition: nodeFromStage(stage.leftStage),
+			True:      nodeFromStage(stage.rightStage),
+		}
+
+	case TERNARY_FALSE:
+		if stage.leftStage != nil && stage.leftStage.symbol == TERNARY_TRUE {
+			return &Ternary{
+				Condition: nodeFromStage(stage.leftStage.leftStage),
+				True:      nodeFromStage(stage.leftStage.rightStage),
+				False:     nodeFromStage(stage.rightStage),
+			}
+		}
+	}
+
+	return &BinaryOp{
+		Operator: stage.symbol,
+		Left:     nodeFromStage(stage.leftStage),
+		Right:    nodeFromStage(stage.rightStage),
+	}
+}
+
+/*
+Returns the arguments given to a function or method, from the stage which holds its parenthesized arguments.
+*/
+func argumentsFromStage(stage *evaluationStage) []Node {
+
+	node := nodeFromStage(stage)
+
+	// arguments which are all known are a single array, which is spread into the arguments when the function is called.
+	if list, ok := node.(*List); ok && isLiteralStage(stage) {
+		return list.Elements
+	}
+
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case *Array:
+		return node.Elements
+	default:
+		return []Node{node}
+	}
+}
+
+/*
+Returns the node of a literal [value]. Arrays become Lists of their elements.
+*/
+func literalNode(value interface{}) Node {
+
+	values, ok := value.([]interface{})
+	if !ok {
+		return &Literal{Value: value}
+	}
+
+	ret := &List{}
+	for _, element := range values {
+		ret.Elements = append(ret.Elements, literalNode(element))
+	}
+	return ret
+}
+
+/*
+Returns true if [stage] is a literal, possibly in parenthesis.
+*/
+func isLiteralStage(stage *evaluationStage) bool {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+	return stage != nil && stage.symbol == LITERAL
+}
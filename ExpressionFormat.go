@@ -0,0 +1,613 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+How tightly each kind of node binds to its operands, from loosest to tightest.
+This follows the order in which `stagePlanner` plans precedence levels, rather than `operatorPrecedence`.
+*/
+const (
+	separatorFormatPrecedence = iota
+	ternaryFormatPrecedence
+	logicalOrFormatPrecedence
+	logicalAndFormatPrecedence
+	comparatorFormatPrecedence
+	bitwiseFormatPrecedence
+	bitwiseShiftFormatPrecedence
+	additiveFormatPrecedence
+	multiplicativeFormatPrecedence
+	exponentialFormatPrecedence
+	prefixFormatPrecedence
+	valueFormatPrecedence
+)
+
+// the formats times are written in, which are among those recognized as date literals. Fractions of a second are only written when there are any.
+const (
+	literalTimeFormat         = "2006-01-02 15:04:05-07:00"
+	literalFractionTimeFormat = "2006-01-02T15:04:05.000000000-07:00"
+)
+
+/*
+Returns this expression as normalized source text, derived from its syntax tree (see `AST`) rather than the text it was created from.
+Operators are separated by single spaces, strings are single-quoted, and only the parenthesis needed to keep the meaning of the expression are kept.
+Formatting an expression, parsing the result, and formatting that again gives the same text.
+
+Returns an error if the expression contains something which can't be written as source text,
+such as a function call in an expression created by `NewEvaluableExpressionFromTokens` (which doesn't record function names).
+*/
+func (e EvaluableExpression) Format() (string, error) {
+
+	return FormatNode(e.AST())
+}
+
+/*
+Parses the given [expression] and returns it formatted as `EvaluableExpression.Format` would.
+[functions] must contain every function the expression calls, but they are never run, and may be nil if there are none.
+*/
+func FormatExpression(expression string, functions map[string]ExpressionFunction) (string, error) {
+
+	parsed, err := NewEvaluableExpressionWithFunctions(expression, functions)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.Format()
+}
+
+/*
+Returns the source text of the syntax tree rooted at [node], as `EvaluableExpression.Format` would.
+A nil [node] is formatted as an empty string.
+*/
+func FormatNode(node Node) (string, error) {
+
+	if node == nil {
+		return "", nil
+	}
+
+	writer := new(expressionWriter)
+
+	err := writer.writeNode(node, false)
+	if err != nil {
+		return "", err
+	}
+
+	return writer.text.String(), nil
+}
+
+/*
+Writes a syntax tree out as source text, and at the same time as the tokens that text would be parsed into,
+so that an expression can be created from a syntax tree without parsing it again.
+*/
+type expressionWriter struct {
+	text      strings.Builder
+	tokens    []ExpressionToken
+	positions tokenPositions
+
+	// if non-nil, the functions (by name) that function calls refer to. Every function called must be present.
+	// if nil, function tokens have no value.
+	functions map[string]interface{}
+}
+
+/*
+Writes a single token, along with its source [text].
+*/
+func (w *expressionWriter) writeToken(kind TokenKind, value interface{}, text string) {
+
+	start := w.text.Len()
+	w.text.WriteString(text)
+
+	w.tokens = append(w.tokens, ExpressionToken{Kind: kind, Value: value})
+	w.positions.spans = append(w.positions.spans, tokenSpan{start: start, end: w.text.Len()})
+}
+
+/*
+Writes the path of an accessor which has optional names, as in `foo?.Bar`, along with the token the lexer would give it.
+*/
+func (w *expressionWriter) writeOptionalAccessor(node *Accessor) {
+
+	var text strings.Builder
+	path := make([]string, len(node.Path))
+
+	for i, name := range node.Path {
+
+		path[i] = name
+		if i > 0 && node.Optional[i] {
+			path[i] = "?" + name
+			text.WriteString("?")
+		}
+		if i > 0 {
+			text.WriteString(".")
+		}
+		text.WriteString(name)
+	}
+
+	w.writeToken(ACCESSOR, path, text.String())
+}
+
+/*
+Returns an expression made from everything written so far.
+*/
+func (w *expressionWriter) expression(options ExpressionOptions) (*EvaluableExpression, error) {
+
+	var err error
+
+	ret := new(EvaluableExpression)
+	ret.QueryDateFormat = isoDateFormat
+	ret.ChecksTypes = true
+	ret.inputExpression = w.text.String()
+	ret.options = options
+
+	ret.positions = &w.positions
+	ret.positions.source = ret.inputExpression
+
+	ret.tokens, err = optimizeTokens(w.tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret.tokens) == 0 {
+		return ret, nil
+	}
+
+	ret.evaluationStages, err = planStages(ret.tokens, ret.positions, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+/*
+Writes [node].
+[followed] is true if anything other than a closing parenthesis (or the end of the expression) will be written after [node].
+*/
+func (w *expressionWriter) writeNode(node Node, followed bool) error {
+
+	switch node := node.(type) {
+
+	case *Literal:
+		return w.writeLiteral(node.Value)
+
+	case *Variable:
+		w.writeToken(VARIABLE, node.Name, formatVariableName(node.Name))
+
+	case *Accessor:
+		for i, name := range node.Path {
+			if !isFormattableName(name, i == 0) {
+				return fmt.Errorf("Unable to format accessor '%s', '%s' is not a valid field or method name", strings.Join(node.Path, "."), name)
+			}
+		}
+
+		if node.Optional != nil && len(node.Optional) != len(node.Path) {
+			return fmt.Errorf("Unable to format accessor '%s', it has %d optional flags for %d names", strings.Join(node.Path, "."), len(node.Optional), len(node.Path))
+		}
+
+		if node.Optional == nil {
+			w.writeToken(ACCESSOR, node.Path, strings.Join(node.Path, "."))
+		} else {
+			w.writeOptionalAccessor(node)
+		}
+
+		if node.Call {
+			return w.writeArguments(node.Arguments)
+		}
+
+	case *FunctionCall:
+		if !isFormattableName(node.Name, true) {
+			return fmt.Errorf("Unable to format call to function '%s', it has no valid name", node.Name)
+		}
+
+		var function interface{}
+
+		if w.functions != nil {
+
+			var found bool
+
+			function, found = w.functions[node.Name]
+			if !found {
+				return fmt.Errorf("Unable to find function '%s'", node.Name)
+			}
+		}
+
+		w.writeToken(FUNCTION, function, node.Name)
+		return w.writeArguments(node.Arguments)
+
+	case *Array:
+		if len(node.Elements) == 0 {
+			return errors.New("Unable to format an empty array")
+		}
+		return w.writeArguments(node.Elements)
+
+	case *List:
+		return w.writeElements(node.Elements, LIST, '{', LIST_CLOSE, '}')
+
+	case *Index:
+		return w.writeIndex(node)
+
+	case *Quantifier:
+		if !quantifierNames[node.Name] {
+			return fmt.Errorf("Unable to format quantifier '%s', it is not one of any, all, none, filter or map", node.Name)
+		}
+
+		w.writeToken(QUANTIFIER, node.Name, node.Name)
+		return w.writeArguments([]Node{node.Collection, node.Expression})
+
+	case *Item:
+		w.writeToken(ITEM, '#', "#")
+
+	case *UnaryOp:
+		w.writeToken(PREFIX, node.Operator.String(), node.Operator.String())
+		return w.writeOperand(node.Operand, valueFormatPrecedence, followed)
+
+	case *BinaryOp:
+		precedence := formatPrecedence(node)
+
+		err := w.writeOperand(node.Left, precedence, true)
+		if err != nil {
+			return err
+		}
+
+		w.writeOperator(node.Operator)
+
+		// operators of the same precedence are evaluated left-to-right, so a right operand of the same precedence needs parenthesis.
+		return w.writeOperand(node.Right, precedence+1, followed)
+
+	case *Ternary:
+		err := w.writeOperand(node.Condition, ternaryFormatPrecedence, true)
+		if err != nil {
+			return err
+		}
+
+		w.writeOperator(TERNARY_TRUE)
+
+		err = w.writeOperand(node.True, ternaryFormatPrecedence+1, followed || node.False != nil)
+		if err != nil || node.False == nil {
+			return err
+		}
+
+		w.writeOperator(TERNARY_FALSE)
+		return w.writeOperand(node.False, ternaryFormatPrecedence+1, followed)
+
+	case nil:
+		return errors.New("Unable to format an expression with a missing operand")
+
+	default:
+		return fmt.Errorf("Unable to format node of type %T", node)
+	}
+
+	return nil
+}
+
+/*
+Writes [node] as an operand of something with the given [precedence], wrapping it in parenthesis if it binds less tightly than that.
+*/
+func (w *expressionWriter) writeOperand(node Node, precedence int, followed bool) error {
+
+	// method calls consume everything after them as arguments unless they're closed off by a parenthesis.
+	accessor, isAccessor := node.(*Accessor)
+	isCall := isAccessor && accessor.Call
+
+	if formatPrecedence(node) >= precedence && !(followed && isCall) {
+		return w.writeNode(node, followed)
+	}
+
+	w.writeToken(CLAUSE, '(', "(")
+
+	err := w.writeNode(node, false)
+	if err != nil {
+		return err
+	}
+
+	w.writeToken(CLAUSE_CLOSE, ')', ")")
+	return nil
+}
+
+/*
+Writes a parenthesized, comma-separated list of [nodes].
+*/
+func (w *expressionWriter) writeArguments(nodes []Node) error {
+	return w.writeElements(nodes, CLAUSE, '(', CLAUSE_CLOSE, ')')
+}
+
+/*
+Writes a comma-separated list of [nodes], between the given [open] and [close] tokens.
+*/
+func (w *expressionWriter) writeElements(nodes []Node, openKind TokenKind, open rune, closeKind TokenKind, close rune) error {
+
+	w.writeToken(openKind, open, string(open))
+
+	for i, node := range nodes {
+
+		if i > 0 {
+			w.writeToken(SEPARATOR, ",", ",")
+			w.text.WriteString(" ")
+		}
+
+		err := w.writeOperand(node, ternaryFormatPrecedence, i < len(nodes)-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.writeToken(closeKind, close, string(close))
+	return nil
+}
+
+/*
+Writes an index, or a field which follows an index.
+*/
+func (w *expressionWriter) writeIndex(node *Index) error {
+
+	var err error
+
+	// only some values can be followed directly by an index, anything else (such as a number) needs parenthesis.
+	switch target := node.Target.(type) {
+	case *Variable, *FunctionCall, *List, *Index, *Quantifier, *Item:
+		err = w.writeNode(target, true)
+	case *Accessor:
+		err = w.writeOperand(target, valueFormatPrecedence, true)
+	case *Literal:
+		if _, isString := target.Value.(string); isString {
+			err = w.writeNode(target, true)
+			break
+		}
+		err = w.writeOperand(target, valueFormatPrecedence+1, true)
+	default:
+		err = w.writeOperand(target, valueFormatPrecedence+1, true)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if node.Key == nil {
+
+		if !isFormattableName(node.Field, false) {
+			return fmt.Errorf("Unable to format field '%s', it is not a valid field name", node.Field)
+		}
+
+		w.writeToken(FIELD, []string{node.Field}, "."+node.Field)
+		return nil
+	}
+
+	w.writeToken(INDEX, '[', "[")
+
+	err = w.writeNode(node.Key, false)
+	if err != nil {
+		return err
+	}
+
+	w.writeToken(INDEX_CLOSE, ']', "]")
+	return nil
+}
+
+/*
+Writes a binary operator, surrounded by spaces.
+*/
+func (w *expressionWriter) writeOperator(symbol OperatorSymbol) {
+
+	var kind TokenKind
+
+	switch symbol {
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN:
+		kind = COMPARATOR
+	case AND, OR:
+		kind = LOGICALOP
+	case TERNARY_TRUE, TERNARY_FALSE, COALESCE:
+		kind = TERNARY
+	case SEPARATE:
+		kind = SEPARATOR
+	default:
+		kind = MODIFIER
+	}
+
+	text := operatorText(symbol)
+
+	w.text.WriteString(" ")
+	w.writeToken(kind, text, text)
+	w.text.WriteString(" ")
+}
+
+/*
+Returns the text the given operator is written as in an expression.
+*/
+func operatorText(symbol OperatorSymbol) string {
+
+	switch symbol {
+	case EQ:
+		return "=="
+	case SEPARATE:
+		return ","
+	}
+	return symbol.String()
+}
+
+func (w *expressionWriter) writeLiteral(value interface{}) error {
+
+	text, err := formatLiteral(value)
+	if err != nil {
+		return err
+	}
+
+	switch value.(type) {
+	case nil:
+		w.writeToken(NIL, value, text)
+	case bool:
+		w.writeToken(BOOLEAN, value, text)
+	case string:
+		w.writeToken(STRING, value, text)
+	case *regexp.Regexp:
+		w.writeToken(PATTERN, value, text)
+	case time.Time:
+		w.writeToken(TIME, value, text)
+	default:
+		w.writeToken(NUMERIC, value, text)
+	}
+	return nil
+}
+
+func formatPrecedence(node Node) int {
+
+	switch node := node.(type) {
+
+	case *Ternary:
+		return ternaryFormatPrecedence
+
+	case *UnaryOp:
+		return prefixFormatPrecedence
+
+	case *Literal:
+		// negative numbers are written with a leading '-', the same as negation.
+		if literal, err := formatLiteral(node.Value); err == nil && strings.HasPrefix(literal, "-") {
+			return prefixFormatPrecedence
+		}
+
+	case *BinaryOp:
+		switch node.Operator {
+		case SEPARATE:
+			return separatorFormatPrecedence
+		case TERNARY_TRUE, TERNARY_FALSE, COALESCE:
+			return ternaryFormatPrecedence
+		case OR:
+			return logicalOrFormatPrecedence
+		case AND:
+			return logicalAndFormatPrecedence
+		case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN:
+			return comparatorFormatPrecedence
+		case BITWISE_AND, BITWISE_OR, BITWISE_XOR:
+			return bitwiseFormatPrecedence
+		case BITWISE_LSHIFT, BITWISE_RSHIFT:
+			return bitwiseShiftFormatPrecedence
+		case PLUS, MINUS:
+			return additiveFormatPrecedence
+		case MULTIPLY, DIVIDE, MODULUS:
+			return multiplicativeFormatPrecedence
+		case EXPONENT:
+			return exponentialFormatPrecedence
+		}
+	}
+
+	return valueFormatPrecedence
+}
+
+func formatLiteral(value interface{}) (string, error) {
+
+	switch value := value.(type) {
+
+	case nil:
+		return "nil", nil
+
+	case bool:
+		return strconv.FormatBool(value), nil
+
+	case string:
+		return quoteString(value), nil
+
+	case *regexp.Regexp:
+		return quoteString(value.String()), nil
+
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return "", fmt.Errorf("Unable to format the number %v, it has no literal form", value)
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+
+	case *big.Rat:
+		return formatDecimal(value), nil
+
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", value), nil
+
+	case float32:
+		return formatLiteral(float64(value))
+
+	case time.Time:
+		if value.Nanosecond() != 0 {
+			return quoteString(value.Format(literalFractionTimeFormat)), nil
+		}
+		return quoteString(value.Format(literalTimeFormat)), nil
+	}
+
+	return "", fmt.Errorf("Unable to format literal '%v' of type %T", value, value)
+}
+
+func quoteString(value string) string {
+
+	var builder strings.Builder
+
+	builder.WriteString("'")
+	for _, character := range value {
+
+		// the lexer ends a string at either kind of quote.
+		if character == '\\' || !isNotQuote(character) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(character)
+	}
+	builder.WriteString("'")
+
+	return builder.String()
+}
+
+/*
+Returns the given parameter [name] as it must be written in an expression; bare if possible, otherwise in brackets.
+*/
+func formatVariableName(name string) string {
+
+	switch name {
+	case "true", "false", "nil", "null", "in", "IN":
+		// these would be read as keywords, not parameters.
+	default:
+		if isFormattableName(name, true) {
+			return name
+		}
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("[")
+	for _, character := range name {
+
+		if character == '\\' || character == ']' {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(character)
+	}
+	builder.WriteString("]")
+
+	return builder.String()
+}
+
+/*
+Returns true if [name] can be written as-is, as a parameter, function, or accessor name.
+Names which aren't [first] in an accessor path may start with a digit.
+*/
+func isFormattableName(name string, first bool) bool {
+
+	if name == "" {
+		return false
+	}
+
+	for i, character := range name {
+
+		if i == 0 && first && !unicode.IsLetter(character) {
+			return false
+		}
+
+		if character == '.' || !isVariableName(character) {
+			return false
+		}
+	}
+
+	return true
+}
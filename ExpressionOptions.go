@@ -0,0 +1,67 @@
+package govaluate
+
+import (
+	"time"
+)
+
+/*
+Represents how numbers are handled by an expression.
+*/
+type NumericMode int
+
+const (
+
+	// All numbers (literals and parameters alike) are converted to float64. This is the default.
+	FLOAT_NUMERICS NumericMode = iota
+
+	// Integer literals and integer parameters are kept as integers, and arithmetic between two integers produces an integer.
+	// Integers are represented as int64, or uint64 for values too large for an int64.
+	// Arithmetic which involves a float, or whose result is not a whole number (such as `7 / 2`), or which would overflow, produces a float64.
+	INTEGER_NUMERICS
+
+	// All numbers are represented as exact decimals, using *big.Rat. Results which are numbers are also *big.Rat.
+	// Float parameters are converted from their shortest decimal representation, so a float64 `0.1` is exactly 1/10.
+	// This avoids rounding errors such as `0.1 + 0.2` producing 0.30000000000000004, at the cost of speed.
+	DECIMAL_NUMERICS
+)
+
+/*
+Options which change how an expression is parsed and evaluated.
+The zero value gives the same behavior as `NewEvaluableExpression`.
+*/
+type ExpressionOptions struct {
+	Numerics NumericMode
+
+	// functions available to the expression, along with what's known about them (see `FunctionDefinition`).
+	Functions map[string]FunctionDefinition
+
+	// the time zone that date literals without one are parsed in, and that standard time functions (like `day`) read numbers given as times in.
+	// If nil, `time.Local` is used.
+	Location *time.Location
+
+	// additional layouts (see `time.Parse`) that string literals are parsed as dates with, before the standard ones are tried.
+	DateLayouts []string
+
+	// if true, string literals are never parsed as dates, and always remain strings.
+	DisableDateLiterals bool
+
+	// if true, parameters which aren't given (those the `Parameters` return a `MissingParameterError` for) evaluate to nil, rather than failing evaluation.
+	// Partial evaluation still treats them as unknown.
+	MissingParametersAsNil bool
+
+	// the struct tag (such as "json") whose names accessors and indexes can use for fields, in addition to their Go names.
+	// `user.first_name` then finds a field tagged `json:"first_name"`. Fields tagged "-" can only be used by their exact Go names.
+	FieldTag string
+
+	// if true, accessors and indexes find fields by names (Go or tag names) which only differ from theirs by case, so `user.name` finds `Name`.
+	CaseInsensitiveFields bool
+}
+
+/*
+Similar to [NewEvaluableExpressionWithFunctions], except that the given [options] change how the expression is parsed and evaluated.
+[functions] may be nil.
+*/
+func NewEvaluableExpressionWithOptions(expression string, functions map[string]ExpressionFunction, options ExpressionOptions) (*EvaluableExpression, error) {
+
+	return newEvaluableExpression(expression, functions, nil, options)
+}
@@ -45,6 +45,10 @@ const (
 	FUNCTIONAL
 	ACCESS
 	SEPARATE
+	LIST_LITERAL
+	INDEX_ACCESS
+	QUANTIFY
+	ITEM_VALUE
 )
 
 type operatorPrecedence int
@@ -53,6 +57,8 @@ const (
 	noopPrecedence operatorPrecedence = iota
 	valuePrecedence
 	functionalPrecedence
+	indexPrecedence
+	quantifierPrecedence
 	prefixPrecedence
 	exponentialPrecedence
 	additivePrecedence
@@ -133,6 +139,10 @@ func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
 		fallthrough
 	case FUNCTIONAL:
 		return functionalPrecedence
+	case INDEX_ACCESS:
+		return indexPrecedence
+	case QUANTIFY:
+		return quantifierPrecedence
 	case SEPARATE:
 		return separatePrecedence
 	}
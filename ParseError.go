@@ -0,0 +1,205 @@
+package govaluate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Represents the different reasons an expression can fail to parse.
+*/
+type ParseErrorCode int
+
+const (
+	PARSE_UNKNOWN ParseErrorCode = iota
+
+	PARSE_INVALID_TOKEN
+	PARSE_INVALID_NUMERIC
+	PARSE_INVALID_HEX
+	PARSE_UNCLOSED_STRING
+	PARSE_UNCLOSED_BRACKET
+	PARSE_HANGING_ACCESSOR
+	PARSE_UNBALANCED_PARENTHESIS
+	PARSE_UNBALANCED_BRACE
+	PARSE_UNBALANCED_BRACKET
+	PARSE_UNDEFINED_FUNCTION
+	PARSE_INVALID_TRANSITION
+	PARSE_UNEXPECTED_END
+	PARSE_NIL_VALUE
+	PARSE_UNBOUND_ITEM
+
+	// found by type checking an expression (see `EvaluableExpression.TypeCheck`), rather than parsing it.
+	PARSE_TYPE_MISMATCH
+	PARSE_UNDECLARED_PARAMETER
+	PARSE_INVALID_ACCESSOR
+	PARSE_ARGUMENT_COUNT
+)
+
+/*
+Returns a string that describes the given ParseErrorCode.
+e.g., when passed PARSE_UNCLOSED_STRING, this returns the string "UNCLOSED_STRING".
+*/
+func (code ParseErrorCode) String() string {
+
+	switch code {
+
+	case PARSE_INVALID_TOKEN:
+		return "INVALID_TOKEN"
+	case PARSE_INVALID_NUMERIC:
+		return "INVALID_NUMERIC"
+	case PARSE_INVALID_HEX:
+		return "INVALID_HEX"
+	case PARSE_UNCLOSED_STRING:
+		return "UNCLOSED_STRING"
+	case PARSE_UNCLOSED_BRACKET:
+		return "UNCLOSED_BRACKET"
+	case PARSE_HANGING_ACCESSOR:
+		return "HANGING_ACCESSOR"
+	case PARSE_UNBALANCED_PARENTHESIS:
+		return "UNBALANCED_PARENTHESIS"
+	case PARSE_UNBALANCED_BRACE:
+		return "UNBALANCED_BRACE"
+	case PARSE_UNBALANCED_BRACKET:
+		return "UNBALANCED_BRACKET"
+	case PARSE_UNDEFINED_FUNCTION:
+		return "UNDEFINED_FUNCTION"
+	case PARSE_INVALID_TRANSITION:
+		return "INVALID_TRANSITION"
+	case PARSE_UNEXPECTED_END:
+		return "UNEXPECTED_END"
+	case PARSE_NIL_VALUE:
+		return "NIL_VALUE"
+	case PARSE_UNBOUND_ITEM:
+		return "UNBOUND_ITEM"
+	case PARSE_TYPE_MISMATCH:
+		return "TYPE_MISMATCH"
+	case PARSE_UNDECLARED_PARAMETER:
+		return "UNDECLARED_PARAMETER"
+	case PARSE_INVALID_ACCESSOR:
+		return "INVALID_ACCESSOR"
+	case PARSE_ARGUMENT_COUNT:
+		return "ARGUMENT_COUNT"
+	}
+
+	return "UNKNOWN"
+}
+
+/*
+ParseError is returned when an expression cannot be parsed, and describes where in the expression the problem lies.
+
+Errors from expressions created with `NewEvaluableExpressionFromTokens` have no source text to point into;
+in that case [Offset] is -1, and [Line] and [Column] are 0.
+*/
+type ParseError struct {
+
+	// the kind of problem that was encountered.
+	Code ParseErrorCode
+
+	// byte offset into the expression at which the offending token starts.
+	Offset int
+
+	// line (starting at 1) and column (starting at 1, counted in characters) of [Offset].
+	Line   int
+	Column int
+
+	// the source text of the offending token, if there was one.
+	Token string
+
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+/*
+Creates a ParseError which points at the given byte [offset] within [source].
+If [offset] is negative, the error is not tied to any position.
+*/
+func newParseError(code ParseErrorCode, source string, offset int, token string, message string) *ParseError {
+
+	ret := &ParseError{
+		Code:    code,
+		Offset:  -1,
+		Token:   token,
+		Message: message,
+	}
+
+	if offset < 0 {
+		return ret
+	}
+
+	if offset > len(source) {
+		offset = len(source)
+	}
+
+	ret.Offset = offset
+	ret.Line = 1 + strings.Count(source[:offset], "\n")
+	ret.Column = 1 + utf8.RuneCountInString(source[strings.LastIndex(source[:offset], "\n")+1:offset])
+	return ret
+}
+
+/*
+The byte range a single token occupies in the source expression it was parsed from.
+*/
+type tokenSpan struct {
+	start int
+	end   int
+}
+
+/*
+Records where each token parsed from an expression string came from, so that errors can point back at them.
+A nil *tokenPositions is valid, and produces errors without any position.
+*/
+type tokenPositions struct {
+	source string
+	spans  []tokenSpan
+}
+
+/*
+Creates a ParseError pointing at the token at [index].
+An [index] past the last token points just after the end of the last token.
+*/
+func (p *tokenPositions) errorAt(index int, code ParseErrorCode, message string) *ParseError {
+
+	if p == nil || len(p.spans) == 0 || index < 0 {
+		return newParseError(code, "", -1, "", message)
+	}
+
+	if index >= len(p.spans) {
+		return newParseError(code, p.source, p.spans[len(p.spans)-1].end, "", message)
+	}
+
+	span := p.spans[index]
+	return newParseError(code, p.source, span.start, p.source[span.start:span.end], message)
+}
+
+/*
+Returns the source text of the token at [index], or an empty string if it isn't known.
+*/
+func (p *tokenPositions) text(index int) string {
+
+	if p == nil || index < 0 || index >= len(p.spans) {
+		return ""
+	}
+
+	span := p.spans[index]
+	return p.source[span.start:span.end]
+}
+
+/*
+Adds the span of a token that was read from [start] up to [end], excluding any whitespace the lexer consumed after it.
+*/
+func (p *tokenPositions) add(start int, end int) {
+
+	for end > start {
+		character, size := utf8.DecodeLastRuneInString(p.source[:end])
+		if !unicode.IsSpace(character) {
+			break
+		}
+		end -= size
+	}
+
+	p.spans = append(p.spans, tokenSpan{start: start, end: end})
+}
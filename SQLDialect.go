@@ -0,0 +1,145 @@
+package govaluate
+
+import (
+	"strings"
+)
+
+/*
+Describes how a database writes the parts of a query which differ between databases, for `ToSQLQueryFor`.
+Dialects are usually one of the ones below, or a copy of one with some of its fields changed.
+*/
+type SQLDialect struct {
+
+	// the name of the database, which is used in errors about what it doesn't support.
+	Name string
+
+	// writes the name of a column, quoted as the database quotes identifiers. If nil, names are bracketed, as in `[name]`.
+	QuoteIdentifier func(name string) string
+
+	// the literals for true and false.
+	True  string
+	False string
+
+	// the operators that `=~` and `!~` are written as. If empty, patterns can't be written for this database.
+	Match    string
+	NotMatch string
+
+	// the function that `**` is written as. If empty, exponents can't be written for this database.
+	Power string
+
+	// the function that `%` is written as. If empty, `%` is written as an operator.
+	Modulus string
+
+	// the layout (see `time.Format`) times are written with. If empty, the expression's `QueryDateFormat` is used.
+	DateFormat string
+
+	// the placeholders that `ToSQLQueryWithArgsFor` writes.
+	Placeholders SQLPlaceholderStyle
+
+	// writes calls to the expression's functions, by the names they were given in the expression.
+	// Functions which aren't given here can't be written.
+	Functions map[string]SQLFunction
+}
+
+/*
+Writes a call to a function, given the SQL for each of its arguments.
+*/
+type SQLFunction func(arguments []string) (string, error)
+
+/*
+Returns an SQLFunction which writes calls to the database function with the given [name], as in `NAME(a, b)`.
+*/
+func SQLFunctionCall(name string) SQLFunction {
+	return func(arguments []string) (string, error) {
+		return name + "(" + strings.Join(arguments, ", ") + ")", nil
+	}
+}
+
+var (
+
+	// The dialect `ToSQLQuery` writes, which mixes that of MySQL (such as `RLIKE`) with that of SQL Server (such as `[name]`).
+	DefaultSQLDialect = SQLDialect{
+		Name:            "SQL",
+		QuoteIdentifier: bracketIdentifier,
+		True:            "1",
+		False:           "0",
+		Match:           "RLIKE",
+		NotMatch:        "NOT RLIKE",
+		Power:           "POW",
+		Modulus:         "MOD",
+	}
+
+	MySQLDialect = SQLDialect{
+		Name:            "MySQL",
+		QuoteIdentifier: quoteIdentifierWith("`"),
+		True:            "TRUE",
+		False:           "FALSE",
+		Match:           "REGEXP",
+		NotMatch:        "NOT REGEXP",
+		Power:           "POW",
+		Modulus:         "MOD",
+		DateFormat:      "2006-01-02 15:04:05.999999",
+		Placeholders:    QUESTION_PLACEHOLDERS,
+	}
+
+	PostgreSQLDialect = SQLDialect{
+		Name:            "PostgreSQL",
+		QuoteIdentifier: quoteIdentifierWith(`"`),
+		True:            "TRUE",
+		False:           "FALSE",
+		Match:           "~",
+		NotMatch:        "!~",
+		Power:           "POWER",
+		Modulus:         "MOD",
+		DateFormat:      "2006-01-02 15:04:05.999999-07:00",
+		Placeholders:    DOLLAR_PLACEHOLDERS,
+	}
+
+	// SQLite only supports patterns if a REGEXP function has been added to it.
+	SQLiteDialect = SQLDialect{
+		Name:            "SQLite",
+		QuoteIdentifier: quoteIdentifierWith(`"`),
+		True:            "1",
+		False:           "0",
+		Match:           "REGEXP",
+		NotMatch:        "NOT REGEXP",
+		Power:           "POWER",
+		DateFormat:      "2006-01-02 15:04:05.999",
+		Placeholders:    QUESTION_PLACEHOLDERS,
+	}
+
+	// SQL Server has no operator for regular expressions, so patterns can't be written for it.
+	SQLServerDialect = SQLDialect{
+		Name:            "SQL Server",
+		QuoteIdentifier: bracketIdentifier,
+		True:            "1",
+		False:           "0",
+		Power:           "POWER",
+		DateFormat:      "2006-01-02T15:04:05.999",
+		Placeholders:    AT_PLACEHOLDERS,
+	}
+)
+
+/*
+Returns [name] in brackets, with any closing brackets within it doubled.
+*/
+func bracketIdentifier(name string) string {
+	return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+/*
+Returns a function which quotes names with the given [quote], with any quotes within them doubled.
+*/
+func quoteIdentifierWith(quote string) func(string) string {
+	return func(name string) string {
+		return quote + strings.Replace(name, quote, quote+quote, -1) + quote
+	}
+}
+
+func (d SQLDialect) quoteIdentifier(name string) string {
+
+	if d.QuoteIdentifier == nil {
+		return bracketIdentifier(name)
+	}
+	return d.QuoteIdentifier(name)
+}
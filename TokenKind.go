@@ -11,13 +11,17 @@ const (
 	PREFIX
 	NUMERIC
 	BOOLEAN
+	NIL
 	STRING
 	PATTERN
 	TIME
 	VARIABLE
+	ITEM
 	FUNCTION
+	QUANTIFIER
 	SEPARATOR
 	ACCESSOR
+	FIELD
 
 	COMPARATOR
 	LOGICALOP
@@ -26,6 +30,12 @@ const (
 	CLAUSE
 	CLAUSE_CLOSE
 
+	LIST
+	LIST_CLOSE
+
+	INDEX
+	INDEX_CLOSE
+
 	TERNARY
 )
 
@@ -43,6 +53,8 @@ func (kind TokenKind) String() string {
 		return "NUMERIC"
 	case BOOLEAN:
 		return "BOOLEAN"
+	case NIL:
+		return "NIL"
 	case STRING:
 		return "STRING"
 	case PATTERN:
@@ -51,8 +63,12 @@ func (kind TokenKind) String() string {
 		return "TIME"
 	case VARIABLE:
 		return "VARIABLE"
+	case ITEM:
+		return "ITEM"
 	case FUNCTION:
 		return "FUNCTION"
+	case QUANTIFIER:
+		return "QUANTIFIER"
 	case SEPARATOR:
 		return "SEPARATOR"
 	case COMPARATOR:
@@ -65,10 +81,20 @@ func (kind TokenKind) String() string {
 		return "CLAUSE"
 	case CLAUSE_CLOSE:
 		return "CLAUSE_CLOSE"
+	case LIST:
+		return "LIST"
+	case LIST_CLOSE:
+		return "LIST_CLOSE"
+	case INDEX:
+		return "INDEX"
+	case INDEX_CLOSE:
+		return "INDEX_CLOSE"
 	case TERNARY:
 		return "TERNARY"
 	case ACCESSOR:
 		return "ACCESSOR"
+	case FIELD:
+		return "FIELD"
 	}
 
 	return "UNKNOWN"
@@ -0,0 +1,278 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+Represents the different kinds of values an expression can work with, for type checking (see `EvaluableExpression.TypeCheck`).
+*/
+type TypeKind int
+
+const (
+
+	// Any value at all. Values of this kind are never type errors, since nothing is known about them.
+	ANY_TYPE TypeKind = iota
+
+	NUMBER_TYPE
+	STRING_TYPE
+	BOOL_TYPE
+	TIME_TYPE
+	DURATION_TYPE
+	ARRAY_TYPE
+	MAP_TYPE
+	STRUCT_TYPE
+)
+
+/*
+Returns a string that describes the given TypeKind.
+e.g., when passed NUMBER_TYPE, this returns the string "number".
+*/
+func (kind TypeKind) String() string {
+
+	switch kind {
+
+	case NUMBER_TYPE:
+		return "number"
+	case STRING_TYPE:
+		return "string"
+	case BOOL_TYPE:
+		return "bool"
+	case TIME_TYPE:
+		return "time"
+	case DURATION_TYPE:
+		return "duration"
+	case ARRAY_TYPE:
+		return "array"
+	case MAP_TYPE:
+		return "map"
+	case STRUCT_TYPE:
+		return "struct"
+	}
+
+	return "any"
+}
+
+/*
+The type of a parameter, or of the value of some part of an expression.
+[GoType] may be given for arrays, maps and structs, in which case accessors and indexes on them (such as `foo.Bar` or `foo[0]`) are checked against it,
+and it's used to decide what operators they can be used with.
+*/
+type ValueType struct {
+	Kind   TypeKind
+	GoType reflect.Type
+}
+
+var (
+	AnyType      = ValueType{Kind: ANY_TYPE}
+	NumberType   = ValueType{Kind: NUMBER_TYPE}
+	StringType   = ValueType{Kind: STRING_TYPE}
+	BoolType     = ValueType{Kind: BOOL_TYPE}
+	TimeType     = ValueType{Kind: TIME_TYPE}
+	DurationType = ValueType{Kind: DURATION_TYPE}
+	ArrayType    = ValueType{Kind: ARRAY_TYPE}
+	MapType      = ValueType{Kind: MAP_TYPE}
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/*
+Returns the ValueType of the given [example] value, such as `TypeOf(User{})` for a parameter which will be a User.
+*/
+func TypeOf(example interface{}) ValueType {
+
+	if example == nil {
+		return AnyType
+	}
+	return typeOfGoType(reflect.TypeOf(example))
+}
+
+func typeOfGoType(goType reflect.Type) ValueType {
+
+	switch goType {
+	case timeType:
+		return TimeType
+	case durationType:
+		return DurationType
+	}
+
+	kind := goType.Kind()
+	if kind == reflect.Ptr {
+		kind = goType.Elem().Kind()
+	}
+
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return NumberType
+	case reflect.String:
+		return StringType
+	case reflect.Bool:
+		return BoolType
+	case reflect.Slice, reflect.Array:
+		return ValueType{Kind: ARRAY_TYPE, GoType: goType}
+	case reflect.Map:
+		return ValueType{Kind: MAP_TYPE, GoType: goType}
+	case reflect.Struct:
+		return ValueType{Kind: STRUCT_TYPE, GoType: goType}
+	}
+
+	return AnyType
+}
+
+/*
+Returns a string that describes this type, such as "number", or the Go type of a struct.
+*/
+func (t ValueType) String() string {
+
+	if t.Kind == STRUCT_TYPE && t.GoType != nil {
+		return t.GoType.String()
+	}
+	return t.Kind.String()
+}
+
+/*
+Returns an example value of this type, which behaves the same way as any other value of this type
+when given to the type checks that stages use at evaluation time.
+*/
+func (t ValueType) sample() interface{} {
+
+	switch t.Kind {
+	case NUMBER_TYPE:
+		return 0.0
+	case STRING_TYPE:
+		return ""
+	case BOOL_TYPE:
+		return false
+	case TIME_TYPE:
+		return time.Time{}
+	case DURATION_TYPE:
+		return time.Duration(0)
+	}
+
+	if t.GoType != nil {
+		return reflect.Zero(t.GoType).Interface()
+	}
+
+	switch t.Kind {
+	case ARRAY_TYPE:
+		return []interface{}{}
+	case MAP_TYPE:
+		return map[string]interface{}{}
+	case STRUCT_TYPE:
+		return struct{}{}
+	}
+	return nil
+}
+
+/*
+Describes the arguments a function takes, and what it returns.
+
+The number of arguments a function takes is [MinArguments] to [MaxArguments], where a negative [MaxArguments] means there is no limit.
+If neither is set, the function takes exactly as many arguments as there are in [Arguments],
+or (if [Variadic] is true) at least one fewer than that, since the last may be given any number of times, including none.
+If [Arguments] is also nil, the function may be given any number of arguments, of any type; use an empty slice for functions which take none.
+*/
+type FunctionSignature struct {
+
+	// the type of each argument. Arguments past the end of this take the type of the last one.
+	Arguments []ValueType
+
+	// if true, the last of [Arguments] may be given any number of times (including none).
+	Variadic bool
+
+	MinArguments int
+	MaxArguments int
+
+	Returns ValueType
+}
+
+/*
+Returns the fewest and most arguments that a function with this signature takes. [max] is negative if there is no limit.
+*/
+func (s FunctionSignature) arity() (min int, max int) {
+
+	if s.MinArguments != 0 || s.MaxArguments != 0 {
+
+		if s.Variadic {
+			return s.MinArguments, -1
+		}
+		return s.MinArguments, s.MaxArguments
+	}
+
+	if s.Arguments == nil {
+		return 0, -1
+	}
+
+	if s.Variadic {
+		return len(s.Arguments) - 1, -1
+	}
+	return len(s.Arguments), len(s.Arguments)
+}
+
+/*
+Returns the type of the argument at [index].
+*/
+func (s FunctionSignature) argumentType(index int) ValueType {
+
+	if len(s.Arguments) == 0 {
+		return AnyType
+	}
+
+	if index >= len(s.Arguments) {
+		return s.Arguments[len(s.Arguments)-1]
+	}
+	return s.Arguments[index]
+}
+
+/*
+Returns a message describing why calling the function called [name] with [count] arguments is wrong,
+or an empty string if it isn't.
+*/
+func (s FunctionSignature) arityError(name string, count int) string {
+
+	min, max := s.arity()
+
+	switch {
+
+	case min == max && count != min:
+		return fmt.Sprintf("Function '%s' takes %d arguments, but was given %d", name, min, count)
+
+	case count < min && max < 0:
+		return fmt.Sprintf("Function '%s' takes at least %d arguments, but was given %d", name, min, count)
+
+	case count < min || (max >= 0 && count > max):
+		return fmt.Sprintf("Function '%s' takes %d to %d arguments, but was given %d", name, min, max, count)
+	}
+
+	return ""
+}
+
+/*
+Declares the types of the parameters and functions an expression may use, so that it can be checked before it is ever evaluated.
+*/
+type TypeSchema struct {
+	Parameters map[string]ValueType
+
+	// signatures for any functions that should be checked. Functions without a signature may be given anything, and return anything.
+	Functions map[string]FunctionSignature
+}
+
+/*
+Every problem found by `EvaluableExpression.TypeCheck`.
+*/
+type TypeCheckErrors []*ParseError
+
+func (errors TypeCheckErrors) Error() string {
+
+	messages := make([]string, len(errors))
+	for i, err := range errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
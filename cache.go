@@ -22,6 +22,7 @@ func getParameterStage(name string) (*evaluationStage, error) {
 	operator := makeParameterStage(name)
 	ret := &evaluationStage{
 		operator: operator,
+		name:     name,
 	}
 	storeVal := weak.Make(ret)
 	paramMap.Store(name, storeVal)
@@ -0,0 +1,239 @@
+package govaluate
+
+import (
+	"math/big"
+	"testing"
+)
+
+/*
+Represents a test of expression evaluation with DECIMAL_NUMERICS.
+Expected values are given as strings, and compared as exact decimals where the result is a number.
+*/
+type DecimalEvaluationTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestDecimalNumerics(test *testing.T) {
+
+	evaluationTests := []DecimalEvaluationTest{
+		{
+			Name:     "Addition without float rounding",
+			Input:    "0.1 + 0.2",
+			Expected: "0.3",
+		},
+		{
+			Name:       "Pricing rule",
+			Input:      "price * 0.07 + fee",
+			Parameters: map[string]interface{}{"price": 19.99, "fee": 0.15},
+			Expected:   "1.5493",
+		},
+		{
+			Name:       "Integer parameters",
+			Input:      "quantity * 3.33",
+			Parameters: map[string]interface{}{"quantity": 3},
+			Expected:   "9.99",
+		},
+		{
+			Name:       "big.Rat parameters",
+			Input:      "amount / 3",
+			Parameters: map[string]interface{}{"amount": big.NewRat(1, 1)},
+			Expected:   "1/3",
+		},
+		{
+			Name:     "Subtraction",
+			Input:    "1 - 0.9",
+			Expected: "0.1",
+		},
+		{
+			Name:     "Division",
+			Input:    "10 / 4",
+			Expected: "2.5",
+		},
+		{
+			Name:     "Modulus",
+			Input:    "-5.5 % 2",
+			Expected: "-1.5",
+		},
+		{
+			Name:     "Exponent",
+			Input:    "1.1 ** 2",
+			Expected: "1.21",
+		},
+		{
+			Name:     "Negative exponent",
+			Input:    "2 ** -2",
+			Expected: "0.25",
+		},
+		{
+			Name:     "Large integers",
+			Input:    "18446744073709551616 * 2",
+			Expected: "36893488147419103232",
+		},
+		{
+			Name:     "Bitwise AND",
+			Input:    "100 & 50",
+			Expected: "32",
+		},
+		{
+			Name:     "Shift left",
+			Input:    "1 << 70",
+			Expected: "1180591620717411303424",
+		},
+		{
+			Name:     "Negation",
+			Input:    "-(0.1 + 0.2)",
+			Expected: "-0.3",
+		},
+		{
+			Name:     "Equality",
+			Input:    "0.1 + 0.2 == 0.3",
+			Expected: true,
+		},
+		{
+			Name:       "Equality with parameters",
+			Input:      "total == 0.3",
+			Parameters: map[string]interface{}{"total": float32(0.3)},
+			Expected:   true,
+		},
+		{
+			Name:     "Comparison",
+			Input:    "0.1 + 0.2 <= 0.3",
+			Expected: true,
+		},
+		{
+			Name:     "String comparison",
+			Input:    "'a' < 'b'",
+			Expected: true,
+		},
+		{
+			Name:       "Membership",
+			Input:      "total in (0.1, 0.3)",
+			Parameters: map[string]interface{}{"total": 0.3},
+			Expected:   true,
+		},
+		{
+			Name:     "String concat",
+			Input:    "'$' + 0.1 * 3",
+			Expected: "$0.3",
+		},
+	}
+
+	for _, evaluationTest := range evaluationTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(evaluationTest.Input, nil, ExpressionOptions{Numerics: DECIMAL_NUMERICS})
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: '%s'", evaluationTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(evaluationTest.Parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: '%s'", evaluationTest.Name, err)
+			continue
+		}
+
+		decimal, isDecimal := result.(*big.Rat)
+		expected, expectsDecimal := evaluationTest.Expected.(string)
+
+		if isDecimal && expectsDecimal {
+
+			expectedDecimal, _ := new(big.Rat).SetString(expected)
+			if decimal.Cmp(expectedDecimal) != 0 {
+				test.Errorf("Test '%s' failed: expected '%v', got '%v'", evaluationTest.Name, expectedDecimal, decimal)
+			}
+			continue
+		}
+
+		if result != evaluationTest.Expected {
+			test.Errorf("Test '%s' failed: expected '%v' (%T), got '%v' (%T)", evaluationTest.Name,
+				evaluationTest.Expected, evaluationTest.Expected, result, result)
+		}
+	}
+}
+
+func TestDecimalDivisionByZero(test *testing.T) {
+
+	expression, err := NewEvaluableExpressionWithOptions("1 / (2 - 2)", nil, ExpressionOptions{Numerics: DECIMAL_NUMERICS})
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	_, err = expression.Evaluate(nil)
+	if err == nil {
+		test.Errorf("Expected an error when dividing by zero")
+	}
+}
+
+func TestDecimalTypeErrors(test *testing.T) {
+
+	cases := map[string]string{
+		"3 && true":    "Value '3' cannot be used with the logical operator '&&', it is not a bool",
+		"0.25 ? 1 : 2": "Value '0.25' cannot be used with the ternary operator '?', it is not a bool",
+	}
+
+	for input, expected := range cases {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, ExpressionOptions{Numerics: DECIMAL_NUMERICS})
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		_, err = expression.Evaluate(nil)
+		if err == nil || err.Error() != expected {
+			test.Errorf("Expected '%s' to fail with '%s', got '%v'", input, expected, err)
+		}
+	}
+}
+
+func TestDecimalIndexErrors(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"list":  []int{1, 2},
+		"codes": map[int]string{1: "a"},
+	}
+
+	cases := map[string]string{
+		"list[5]":    "Index 5 is out of range of '[1 2]', which has 2 elements",
+		"list[0.5]":  "Unable to index '[1 2]' with '0.5', it is not a whole number",
+		"codes[2]":   "No key '2' present in 'map[1:a]'",
+		"codes[1.5]": "Unable to index 'map[1:a]' with '1.5', it cannot be used as a key of map[int]string",
+	}
+
+	for input, expected := range cases {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, ExpressionOptions{Numerics: DECIMAL_NUMERICS})
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		_, err = expression.Evaluate(parameters)
+		if err == nil || err.Error() != expected {
+			test.Errorf("Expected '%s' to fail with '%s', got '%v'", input, expected, err)
+		}
+	}
+}
+
+func TestDecimalFormatting(test *testing.T) {
+
+	cases := map[string]string{
+		"7/100": "0.07",
+		"5":     "5",
+		"-1/8":  "-0.125",
+		"1/3":   "0.33333333333333333333",
+	}
+
+	for input, expected := range cases {
+
+		value, _ := new(big.Rat).SetString(input)
+		actual := formatDecimal(value)
+
+		if actual != expected {
+			test.Errorf("Expected '%s' to format as '%s', got '%s'", input, expected, actual)
+		}
+	}
+}
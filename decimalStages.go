@@ -0,0 +1,349 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+/*
+Operators which are used in place of those in `stageSymbolMap` when an expression uses DECIMAL_NUMERICS.
+Every number is represented as an exact *big.Rat (see `castToDecimal`), and every result is one too.
+*/
+var decimalStageSymbolMap = map[OperatorSymbol]evaluationOperator{
+	EQ:             decimalEqualStage,
+	NEQ:            decimalNotEqualStage,
+	GT:             decimalGtStage,
+	LT:             decimalLtStage,
+	GTE:            decimalGteStage,
+	LTE:            decimalLteStage,
+	IN:             decimalInStage,
+	BITWISE_OR:     decimalBitwiseOrStage,
+	BITWISE_AND:    decimalBitwiseAndStage,
+	BITWISE_XOR:    decimalBitwiseXORStage,
+	BITWISE_LSHIFT: decimalLeftShiftStage,
+	BITWISE_RSHIFT: decimalRightShiftStage,
+	PLUS:           decimalAddStage,
+	MINUS:          decimalSubtractStage,
+	MULTIPLY:       decimalMultiplyStage,
+	DIVIDE:         decimalDivideStage,
+	MODULUS:        decimalModulusStage,
+	EXPONENT:       decimalExponentStage,
+	NEGATE:         decimalNegateStage,
+	BITWISE_NOT:    decimalBitwiseNotStage,
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// the largest integer exponent which will be calculated exactly. Anything larger is calculated with floats.
+const maxExactDecimalExponent = 1024
+
+// the largest shift which can be performed on a decimal.
+const maxDecimalShift = 1024
+
+func decimalAddStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	// string concat if either are strings
+	if isString(left) || isString(right) {
+		return fmt.Sprintf("%v%v", formatIfDecimal(left), formatIfDecimal(right)), nil
+	}
+
+	return new(big.Rat).Add(asDecimal(left), asDecimal(right)), nil
+}
+func decimalSubtractStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return new(big.Rat).Sub(asDecimal(left), asDecimal(right)), nil
+}
+func decimalMultiplyStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return new(big.Rat).Mul(asDecimal(left), asDecimal(right)), nil
+}
+func decimalDivideStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	divisor := asDecimal(right)
+	if divisor.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+	return new(big.Rat).Quo(asDecimal(left), divisor), nil
+}
+
+func decimalModulusStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	dividend := asDecimal(left)
+	divisor := asDecimal(right)
+
+	if divisor.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+
+	// same as math.Mod; the result has the sign of the dividend.
+	quotient := new(big.Rat).Quo(dividend, divisor)
+	truncated := new(big.Rat).SetInt(truncateDecimal(quotient))
+
+	return new(big.Rat).Sub(dividend, truncated.Mul(truncated, divisor)), nil
+}
+
+func decimalExponentStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	base := asDecimal(left)
+	exponent := asDecimal(right)
+
+	if exponent.IsInt() && exponent.Num().IsInt64() && math.Abs(float64(exponent.Num().Int64())) <= maxExactDecimalExponent {
+
+		power := exponent.Num().Int64()
+		negative := power < 0
+		if negative {
+			if base.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			power = -power
+		}
+
+		numerator := new(big.Int).Exp(base.Num(), big.NewInt(power), nil)
+		denominator := new(big.Int).Exp(base.Denom(), big.NewInt(power), nil)
+
+		if negative {
+			numerator, denominator = denominator, numerator
+		}
+		return new(big.Rat).SetFrac(numerator, denominator), nil
+	}
+
+	// fractional (or enormous) exponents can't be represented exactly anyway.
+	baseFloat, _ := base.Float64()
+	exponentFloat, _ := exponent.Float64()
+	return castToDecimal(math.Pow(baseFloat, exponentFloat)), nil
+}
+
+func decimalNegateStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return new(big.Rat).Neg(asDecimal(right)), nil
+}
+
+/*
+Bitwise operators act on the integer parts of their operands, the same way they truncate float64 values.
+*/
+func decimalBitwiseOrStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	ret := new(big.Int).Or(truncateDecimal(asDecimal(left)), truncateDecimal(asDecimal(right)))
+	return new(big.Rat).SetInt(ret), nil
+}
+func decimalBitwiseAndStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	ret := new(big.Int).And(truncateDecimal(asDecimal(left)), truncateDecimal(asDecimal(right)))
+	return new(big.Rat).SetInt(ret), nil
+}
+func decimalBitwiseXORStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	ret := new(big.Int).Xor(truncateDecimal(asDecimal(left)), truncateDecimal(asDecimal(right)))
+	return new(big.Rat).SetInt(ret), nil
+}
+func decimalBitwiseNotStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	ret := new(big.Int).Not(truncateDecimal(asDecimal(right)))
+	return new(big.Rat).SetInt(ret), nil
+}
+
+func decimalLeftShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	shift, err := decimalShift(right)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(big.Int).Lsh(truncateDecimal(asDecimal(left)), shift)
+	return new(big.Rat).SetInt(ret), nil
+}
+func decimalRightShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	shift, err := decimalShift(right)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(big.Int).Rsh(truncateDecimal(asDecimal(left)), shift)
+	return new(big.Rat).SetInt(ret), nil
+}
+
+func decimalShift(value interface{}) (uint, error) {
+
+	shift := truncateDecimal(asDecimal(value))
+
+	if shift.Sign() < 0 || shift.Cmp(big.NewInt(maxDecimalShift)) > 0 {
+		return 0, fmt.Errorf("cannot shift by %v, shifts must be between 0 and %d", shift, maxDecimalShift)
+	}
+	return uint(shift.Uint64()), nil
+}
+
+func decimalGteStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) >= right.(string)), nil
+	}
+	return boolIface(asDecimal(left).Cmp(asDecimal(right)) >= 0), nil
+}
+func decimalGtStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) > right.(string)), nil
+	}
+	return boolIface(asDecimal(left).Cmp(asDecimal(right)) > 0), nil
+}
+func decimalLteStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) <= right.(string)), nil
+	}
+	return boolIface(asDecimal(left).Cmp(asDecimal(right)) <= 0), nil
+}
+func decimalLtStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) < right.(string)), nil
+	}
+	return boolIface(asDecimal(left).Cmp(asDecimal(right)) < 0), nil
+}
+func decimalEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return boolIface(decimalsEqual(left, right)), nil
+}
+func decimalNotEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return boolIface(!decimalsEqual(left, right)), nil
+}
+
+func decimalInStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	for _, value := range right.([]interface{}) {
+		if decimalsEqual(left, castToDecimal(value)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/*
+Equality which compares numbers by value. Anything else is compared the same way the `==` operator usually does.
+*/
+func decimalsEqual(left interface{}, right interface{}) bool {
+
+	leftDecimal := asDecimal(left)
+	rightDecimal := asDecimal(right)
+
+	if leftDecimal != nil && rightDecimal != nil {
+		return leftDecimal.Cmp(rightDecimal) == 0
+	}
+	return valuesEqual(left, right)
+}
+
+/*
+Converts any number to an exact *big.Rat.
+Integers of any width, *big.Int and *big.Float are converted exactly.
+Floats are converted from the shortest decimal representation that uniquely identifies them, so that a float64 `0.1` becomes exactly 1/10.
+NaN and infinite floats have no decimal representation, and are left as float64.
+*/
+func castToDecimal(value interface{}) interface{} {
+
+	switch value := value.(type) {
+	case *big.Rat:
+		return value
+	case *big.Int:
+		return new(big.Rat).SetInt(value)
+	case *big.Float:
+		if value.IsInf() {
+			return value
+		}
+		ret, _ := value.Rat(nil)
+		return ret
+	case float32:
+		return floatToDecimal(float64(value), 32)
+	case float64:
+		return floatToDecimal(value, 64)
+	}
+
+	switch value := castToInteger(value).(type) {
+	case int64:
+		return new(big.Rat).SetInt64(value)
+	case uint64:
+		return new(big.Rat).SetUint64(value)
+	}
+
+	return value
+}
+
+func floatToDecimal(value float64, bitSize int) interface{} {
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+
+	ret, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, bitSize))
+	return ret
+}
+
+/*
+Returns the given [value] as a *big.Rat, or nil if it isn't a number.
+The returned value must not be modified, since it may be the value of a literal or parameter.
+*/
+func asDecimal(value interface{}) *big.Rat {
+
+	ret, _ := castToDecimal(value).(*big.Rat)
+	return ret
+}
+
+func isDecimalNumber(value interface{}) bool {
+	return asDecimal(value) != nil
+}
+
+/*
+Parses a numeric literal as an exact decimal.
+*/
+func parseDecimalLiteral(literal string) (interface{}, bool) {
+	return new(big.Rat).SetString(literal)
+}
+
+/*
+Returns the integer part of [value], truncated towards zero.
+*/
+func truncateDecimal(value *big.Rat) *big.Int {
+	return new(big.Int).Quo(value.Num(), value.Denom())
+}
+
+/*
+Formats a decimal the way it would be written as a literal, e.g. "0.07" rather than "7/100".
+Values without a finite decimal expansion (such as 1/3) are rounded to 20 decimal places.
+*/
+func formatDecimal(value *big.Rat) string {
+
+	if value.IsInt() {
+		return value.Num().String()
+	}
+
+	// a fraction has a finite decimal expansion only if its denominator has no prime factors besides 2 and 5.
+	// the number of digits needed is the larger of those two factors' powers.
+	denominator := new(big.Int).Set(value.Denom())
+	remainder := new(big.Int)
+	twos, fives := 0, 0
+
+	for {
+		quotient, mod := new(big.Int).QuoRem(denominator, big.NewInt(2), remainder)
+		if mod.Sign() != 0 {
+			break
+		}
+		denominator = quotient
+		twos++
+	}
+	for {
+		quotient, mod := new(big.Int).QuoRem(denominator, big.NewInt(5), remainder)
+		if mod.Sign() != 0 {
+			break
+		}
+		denominator = quotient
+		fives++
+	}
+
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		return value.FloatString(20)
+	}
+
+	if twos > fives {
+		return value.FloatString(twos)
+	}
+	return value.FloatString(fives)
+}
+
+func formatIfDecimal(value interface{}) interface{} {
+
+	if decimal, ok := value.(*big.Rat); ok {
+		return formatDecimal(decimal)
+	}
+	return value
+}
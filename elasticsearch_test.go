@@ -0,0 +1,220 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of creating an Elasticsearch query from an expression.
+*/
+type ElasticsearchQueryTest struct {
+	Name     string
+	Input    string
+	Expected map[string]interface{}
+}
+
+type elasticsearchDocument = map[string]interface{}
+
+func TestElasticsearchQueries(test *testing.T) {
+
+	created := time.Date(2014, 1, 2, 0, 0, 0, 0, time.Local)
+
+	testCases := []ElasticsearchQueryTest{
+
+		{
+			Name:     "Equality",
+			Input:    "name == 'ada'",
+			Expected: elasticsearchDocument{"term": elasticsearchDocument{"name": "ada"}},
+		},
+		{
+			Name:  "Inequality",
+			Input: "name != 'ada'",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must_not": []interface{}{
+				elasticsearchDocument{"term": elasticsearchDocument{"name": "ada"}},
+			}}},
+		},
+		{
+			Name:  "Ranges",
+			Input: "age > 30 && 65 >= age",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must": []interface{}{
+				elasticsearchDocument{"range": elasticsearchDocument{"age": elasticsearchDocument{"gt": 30.0}}},
+				elasticsearchDocument{"range": elasticsearchDocument{"age": elasticsearchDocument{"lte": 65.0}}},
+			}}},
+		},
+		{
+			Name:  "Chained ors",
+			Input: "a == 1 || (b == 2 || c < 3)",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{
+				"should": []interface{}{
+					elasticsearchDocument{"term": elasticsearchDocument{"a": 1.0}},
+					elasticsearchDocument{"term": elasticsearchDocument{"b": 2.0}},
+					elasticsearchDocument{"range": elasticsearchDocument{"c": elasticsearchDocument{"lt": 3.0}}},
+				},
+				"minimum_should_match": 1,
+			}},
+		},
+		{
+			Name:  "Nested operators",
+			Input: "a == 1 && (b == 2 || c == 3)",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must": []interface{}{
+				elasticsearchDocument{"term": elasticsearchDocument{"a": 1.0}},
+				elasticsearchDocument{"bool": elasticsearchDocument{
+					"should": []interface{}{
+						elasticsearchDocument{"term": elasticsearchDocument{"b": 2.0}},
+						elasticsearchDocument{"term": elasticsearchDocument{"c": 3.0}},
+					},
+					"minimum_should_match": 1,
+				}},
+			}}},
+		},
+		{
+			Name:     "Negative number",
+			Input:    "a == -1",
+			Expected: elasticsearchDocument{"term": elasticsearchDocument{"a": -1.0}},
+		},
+		{
+			Name:  "Negative range",
+			Input: "a >= -10 && -1 > a",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must": []interface{}{
+				elasticsearchDocument{"range": elasticsearchDocument{"a": elasticsearchDocument{"gte": -10.0}}},
+				elasticsearchDocument{"range": elasticsearchDocument{"a": elasticsearchDocument{"lt": -1.0}}},
+			}}},
+		},
+		{
+			Name:     "Negative membership",
+			Input:    "a in (-1, 2)",
+			Expected: elasticsearchDocument{"terms": elasticsearchDocument{"a": []interface{}{-1.0, 2.0}}},
+		},
+		{
+			Name:     "Membership",
+			Input:    "role in ('admin', 'owner')",
+			Expected: elasticsearchDocument{"terms": elasticsearchDocument{"role": []interface{}{"admin", "owner"}}},
+		},
+		{
+			Name:     "Pattern",
+			Input:    "host =~ 'web-[0-9]+'",
+			Expected: elasticsearchDocument{"regexp": elasticsearchDocument{"host": "web-[0-9]+"}},
+		},
+		{
+			Name:  "Negated pattern",
+			Input: "host !~ 'web-[0-9]+'",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must_not": []interface{}{
+				elasticsearchDocument{"regexp": elasticsearchDocument{"host": "web-[0-9]+"}},
+			}}},
+		},
+		{
+			Name:     "Not nil",
+			Input:    "user != nil",
+			Expected: elasticsearchDocument{"exists": elasticsearchDocument{"field": "user"}},
+		},
+		{
+			Name:  "Nil",
+			Input: "user == nil",
+			Expected: elasticsearchDocument{"bool": elasticsearchDocument{"must_not": []interface{}{
+				elasticsearchDocument{"exists": elasticsearchDocument{"field": "user"}},
+			}}},
+		},
+		{
+			Name:     "Doubly inverted",
+			Input:    "!(name != 'ada')",
+			Expected: elasticsearchDocument{"term": elasticsearchDocument{"name": "ada"}},
+		},
+		{
+			Name:     "Boolean field",
+			Input:    "event.success",
+			Expected: elasticsearchDocument{"term": elasticsearchDocument{"event.success": true}},
+		},
+		{
+			Name:     "Time",
+			Input:    "'2014-01-02' <= created",
+			Expected: elasticsearchDocument{"range": elasticsearchDocument{"created": elasticsearchDocument{"gte": created}}},
+		},
+		{
+			Name:     "True",
+			Input:    "true",
+			Expected: elasticsearchDocument{"match_all": elasticsearchDocument{}},
+		},
+		{
+			Name:     "False",
+			Input:    "false",
+			Expected: elasticsearchDocument{"match_none": elasticsearchDocument{}},
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		query, err := expression.ToElasticsearchQuery()
+		if err != nil {
+			test.Errorf("Test '%s' failed to create query: %s", testCase.Name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(query, testCase.Expected) {
+			test.Errorf("Test '%s' created %v, expected %v", testCase.Name, query, testCase.Expected)
+		}
+	}
+}
+
+func TestElasticsearchQueryJSON(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("level in ('error', 'fatal') && !(service.name =~ 'test-.*')")
+
+	query, err := expression.ToElasticsearchQuery()
+	if err != nil {
+		test.Fatalf("Failed to create query: %s", err)
+	}
+
+	encoded, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		test.Fatalf("Failed to encode query: %s", err)
+	}
+
+	expected := `{"query":{"bool":{"must":[{"terms":{"level":["error","fatal"]}},{"bool":{"must_not":[{"regexp":{"service.name":"test-.*"}}]}}]}}}`
+	if string(encoded) != expected {
+		test.Errorf("Encoded query as %s, expected %s", encoded, expected)
+	}
+}
+
+func TestElasticsearchQueryFailures(test *testing.T) {
+
+	failures := []string{
+		"a * 2 > 2",
+		"a == b",
+		"'a' < 'b'",
+		"a > 1 ? b : c",
+		"len(name) > 1",
+		"user.Name() == 'ada'",
+		"'abc' =~ name",
+		"any(users, # > 1)",
+		"5",
+		"[$where] == 'sleep(100)'",
+		"[a.$ne] in (1, 2)",
+		"[a\x00b] == 1",
+		"-a > 1",
+	}
+
+	options := ExpressionOptions{Functions: StandardFunctionDefinitions()}
+
+	for _, input := range failures {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Failed to parse '%s': %s", input, err)
+			continue
+		}
+
+		_, err = expression.ToElasticsearchQuery()
+		if err == nil {
+			test.Errorf("Expected '%s' to be unsupported in Elasticsearch queries", input)
+		}
+	}
+}
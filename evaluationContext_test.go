@@ -0,0 +1,140 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type contextParameter struct{}
+
+func (contextParameter) Deadline(ctx context.Context, name string) string {
+	if _, ok := ctx.Deadline(); ok {
+		return name + " has a deadline"
+	}
+	return name + " has no deadline"
+}
+
+func TestEvalContextCancelled(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"count": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return 1.0, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithFunctions("count() + count()", functions)
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = expression.EvalContext(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		test.Errorf("Expected context.Canceled, got '%v'", err)
+	}
+	if calls != 0 {
+		test.Errorf("Expected no functions to run after cancellation, ran %d", calls)
+	}
+}
+
+func TestEvalContextStopsBetweenStages(test *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	functions := map[string]ExpressionFunction{
+		"cancel": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			cancel()
+			return true, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithFunctions("cancel() && cancel()", functions)
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	_, err = expression.EvalContext(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		test.Errorf("Expected context.Canceled, got '%v'", err)
+	}
+	if calls != 1 {
+		test.Errorf("Expected evaluation to stop after the first function, ran %d", calls)
+	}
+}
+
+func TestContextFunctions(test *testing.T) {
+
+	functions := map[string]ContextExpressionFunction{
+		"wait": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(arguments[0].(float64)) * time.Millisecond):
+				return true, nil
+			}
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithContextFunctions("wait(5000)", functions)
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = expression.EvalContext(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		test.Errorf("Expected context.DeadlineExceeded, got '%v'", err)
+	}
+
+	expression, err = NewEvaluableExpressionWithContextFunctions("wait(1)", functions)
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	result, err := expression.Eval(nil)
+	if err != nil {
+		test.Fatalf("Expected no error, got '%v'", err)
+	}
+	if result != true {
+		test.Errorf("Expected 'true', got '%v'", result)
+	}
+}
+
+func TestContextAccessorMethods(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("foo.Deadline('bar')")
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	parameters := MapParameters{"foo": contextParameter{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := expression.EvalContext(ctx, parameters)
+	if err != nil {
+		test.Fatalf("Expected no error, got '%v'", err)
+	}
+	if result != "bar has a deadline" {
+		test.Errorf("Expected accessor to receive the evaluation context, got '%v'", result)
+	}
+
+	result, err = expression.Eval(parameters)
+	if err != nil {
+		test.Fatalf("Expected no error, got '%v'", err)
+	}
+	if result != "bar has no deadline" {
+		test.Errorf("Expected accessor to receive a background context, got '%v'", result)
+	}
+}
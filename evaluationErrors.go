@@ -0,0 +1,125 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+/*
+TypeMismatchError is returned when an operator is given values of types it cannot operate on,
+such as `1 + true`, or `'foo' > 3`.
+*/
+type TypeMismatchError struct {
+	Operator OperatorSymbol
+
+	// the values given to the operator. Prefix operators have no left value.
+	Left  interface{}
+	Right interface{}
+
+	// the types of [Left] and [Right], or nil if the respective value was nil.
+	LeftType  reflect.Type
+	RightType reflect.Type
+
+	message string
+}
+
+func newTypeMismatchError(symbol OperatorSymbol, format string, value interface{}, left interface{}, right interface{}) *TypeMismatchError {
+
+	// decimals are shown as they'd be written, rather than as fractions.
+	if decimal, isDecimal := value.(*big.Rat); isDecimal && decimal != nil {
+		value = formatDecimal(decimal)
+	}
+
+	message := fmt.Sprintf(format, value, symbol.String())
+	if nilFormat, found := nilErrorFormats[format]; found && isNil(value) {
+		message = fmt.Sprintf(nilFormat, symbol.String())
+	}
+
+	return &TypeMismatchError{
+		Operator:  symbol,
+		Left:      left,
+		Right:     right,
+		LeftType:  reflect.TypeOf(left),
+		RightType: reflect.TypeOf(right),
+		message:   message,
+	}
+}
+
+func (e *TypeMismatchError) Error() string {
+	return e.message
+}
+
+/*
+MissingParameterError is returned by `MapParameters` when an expression refers to a parameter which was not given.
+Other `Parameters` should return it for the same reason, so that such parameters are nil in expressions with the `MissingParametersAsNil` option.
+*/
+type MissingParameterError struct {
+	Name string
+}
+
+func (e *MissingParameterError) Error() string {
+	return "No parameter '" + e.Name + "' found."
+}
+
+/*
+FunctionError wraps an error returned by an `ExpressionFunction` (or `ContextExpressionFunction`) during evaluation.
+The original error is available through `errors.Unwrap`, `errors.Is` and `errors.As`.
+*/
+type FunctionError struct {
+
+	// the name the function was called by, if known.
+	// Expressions created with `NewEvaluableExpressionFromTokens` don't know the names of their functions.
+	Name string
+
+	Err error
+}
+
+func (e *FunctionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FunctionError) Unwrap() error {
+	return e.Err
+}
+
+/*
+AccessorError is returned when an accessor (such as `foo.Bar` or `foo.Bar()`) cannot access the field or call the method it refers to.
+If the failure came from an error returned by the method itself, that error is available through `errors.Unwrap`.
+*/
+type AccessorError struct {
+
+	// the full accessor, e.g. "foo.Bar.Baz"
+	Accessor string
+
+	// the part of the accessor which could not be accessed, e.g. "Bar"
+	Field string
+
+	Err error
+
+	message string
+}
+
+func (e *AccessorError) Error() string {
+	return e.message
+}
+
+func (e *AccessorError) Unwrap() error {
+	return e.Err
+}
+
+/*
+IndexError is returned when an index (such as `items[0]` or `headers[name]`) refers to an element which isn't there:
+an index out of the range of an array or string, a key missing from a map, or a field missing from a struct.
+*/
+type IndexError struct {
+
+	// the key that was looked up, e.g. 0 or "name"
+	Key interface{}
+
+	message string
+}
+
+func (e *IndexError) Error() string {
+	return e.message
+}
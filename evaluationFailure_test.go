@@ -5,6 +5,7 @@ package govaluate
 */
 import (
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -506,3 +507,100 @@ func runEvaluationFailureTests(evaluationTests []EvaluationFailureTest, test *te
 		}
 	}
 }
+
+func TestTypeMismatchError(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("number + bool")
+	_, err := expression.Evaluate(EVALUATION_FAILURE_PARAMETERS)
+
+	var mismatch *TypeMismatchError
+	if !errors.As(err, &mismatch) {
+		test.Fatalf("Expected a *TypeMismatchError, got '%v'", err)
+	}
+
+	if mismatch.Operator != PLUS {
+		test.Errorf("Expected operator '+', got '%v'", mismatch.Operator)
+	}
+	if mismatch.Left != 1.0 || mismatch.Right != true {
+		test.Errorf("Expected values 1 and true, got '%v' and '%v'", mismatch.Left, mismatch.Right)
+	}
+	if mismatch.LeftType.Kind() != reflect.Float64 || mismatch.RightType.Kind() != reflect.Bool {
+		test.Errorf("Expected types float64 and bool, got '%v' and '%v'", mismatch.LeftType, mismatch.RightType)
+	}
+
+	expression, _ = NewEvaluableExpression("bool && string")
+	_, err = expression.Evaluate(EVALUATION_FAILURE_PARAMETERS)
+
+	if !errors.As(err, &mismatch) {
+		test.Fatalf("Expected a *TypeMismatchError, got '%v'", err)
+	}
+	if mismatch.Operator != AND || mismatch.Right != "foo" {
+		test.Errorf("Expected '&&' with right value 'foo', got '%v' with '%v'", mismatch.Operator, mismatch.Right)
+	}
+}
+
+func TestMissingParameterError(test *testing.T) {
+
+	for _, input := range []string{"missing > 1", "missing.Field"} {
+
+		expression, _ := NewEvaluableExpression(input)
+		_, err := expression.Evaluate(EVALUATION_FAILURE_PARAMETERS)
+
+		var missing *MissingParameterError
+		if !errors.As(err, &missing) {
+			test.Errorf("Expected a *MissingParameterError from '%s', got '%v'", input, err)
+			continue
+		}
+		if missing.Name != "missing" {
+			test.Errorf("Expected missing parameter 'missing', got '%s'", missing.Name)
+		}
+	}
+}
+
+func TestFunctionError(test *testing.T) {
+
+	problem := errors.New("Huge problems")
+	functions := map[string]ExpressionFunction{
+		"fail": func(arguments ...interface{}) (interface{}, error) {
+			return nil, problem
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("1 + fail(2)", functions)
+	_, err := expression.Evaluate(nil)
+
+	var functionError *FunctionError
+	if !errors.As(err, &functionError) {
+		test.Fatalf("Expected a *FunctionError, got '%v'", err)
+	}
+	if functionError.Name != "fail" {
+		test.Errorf("Expected function name 'fail', got '%s'", functionError.Name)
+	}
+	if !errors.Is(err, problem) {
+		test.Errorf("Expected error to wrap the function's error")
+	}
+}
+
+func TestAccessorError(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("foo.Nested.NotExists")
+	_, err := expression.Evaluate(fooFailureParameters)
+
+	var accessorError *AccessorError
+	if !errors.As(err, &accessorError) {
+		test.Fatalf("Expected an *AccessorError, got '%v'", err)
+	}
+	if accessorError.Accessor != "foo.Nested.NotExists" || accessorError.Field != "NotExists" {
+		test.Errorf("Expected failure on 'NotExists' of 'foo.Nested.NotExists', got '%s' of '%s'", accessorError.Field, accessorError.Accessor)
+	}
+
+	expression, _ = NewEvaluableExpression("foo.AlwaysFail()")
+	_, err = expression.Evaluate(fooFailureParameters)
+
+	if !errors.As(err, &accessorError) {
+		test.Fatalf("Expected an *AccessorError, got '%v'", err)
+	}
+	if accessorError.Err == nil || accessorError.Err.Error() != "function should always fail" {
+		test.Errorf("Expected the method's error to be wrapped, got '%v'", accessorError.Err)
+	}
+}
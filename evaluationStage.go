@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -18,6 +19,19 @@ const (
 	prefixErrorFormat     string = "Value '%v' cannot be used with the prefix '%v'"
 )
 
+/*
+The messages used instead of the formats above when the value is nil, since the reason they give is rarely the problem.
+*/
+var nilErrorFormats = map[string]string{
+	logicalErrorFormat:    "Nil value cannot be used with the logical operator '%v'",
+	modifierErrorFormat:   "Nil value cannot be used with the modifier '%v'",
+	comparatorErrorFormat: "Nil value cannot be used with the comparator '%v'",
+	ternaryErrorFormat:    "Nil value cannot be used with the ternary operator '%v'",
+	prefixErrorFormat:     "Nil value cannot be used with the prefix '%v'",
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 type evaluationOperator func(left interface{}, right interface{}, parameters Parameters) (interface{}, error)
 type stageTypeCheck func(value interface{}) bool
 type stageCombinedTypeCheck func(left interface{}, right interface{}) bool
@@ -41,6 +55,25 @@ type evaluationStage struct {
 
 	// regardless of which type check is used, this string format will be used as the error message for type errors
 	typeErrorFormat string
+
+	// what this stage refers to by name, if anything: the name of a parameter or function, or the path of an accessor.
+	// these aren't needed to evaluate the stage, but are used to rebuild the expression's syntax tree (see `AST`).
+	name string
+	path []string
+
+	// for accessors, whether each name in [path] is accessed optionally (with `?.`). Nil if none are.
+	optional []bool
+
+	// for literals which evaluate to something other than what was written, the value as written, which the syntax tree (see `AST`) uses instead.
+	// Only date literals have one, since they're evaluated as unix times.
+	written interface{}
+
+	// for functions, whether the function is one which always keeps an array given as its only argument (see `useArrayArguments`).
+	keepArrays bool
+
+	// the index of the token this stage was planned from, for stages planned from an operator, function, accessor, or parenthesis.
+	// Parameters and literals don't have one, since their stages may be shared between expressions.
+	tokenIndex int
 }
 
 var (
@@ -63,6 +96,12 @@ func (e *evaluationStage) setToNonStage(other evaluationStage) {
 	e.rightTypeCheck = other.rightTypeCheck
 	e.typeCheck = other.typeCheck
 	e.typeErrorFormat = other.typeErrorFormat
+	e.name = other.name
+	e.path = other.path
+	e.optional = other.optional
+	e.written = other.written
+	e.keepArrays = other.keepArrays
+	e.tokenIndex = other.tokenIndex
 }
 
 func (e *evaluationStage) isShortCircuitable() bool {
@@ -87,6 +126,10 @@ func noopStageRight(left interface{}, right interface{}, parameters Parameters)
 	return right, nil
 }
 
+func noopStageLeft(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return left, nil
+}
+
 func addStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
 	// string concat if either are strings
@@ -136,11 +179,24 @@ func ltStage(left interface{}, right interface{}, parameters Parameters) (interf
 	return boolIface(left.(float64) < right.(float64)), nil
 }
 func equalStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(reflect.DeepEqual(left, right)), nil
+	return boolIface(valuesEqual(left, right)), nil
 }
 func notEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(!reflect.DeepEqual(left, right)), nil
+	return boolIface(!valuesEqual(left, right)), nil
 }
+
+/*
+Returns true if [left] and [right] are deeply equal, or are both nil.
+Nil pointers, maps, slices and the like count as nil, whatever their type.
+*/
+func valuesEqual(left interface{}, right interface{}) bool {
+
+	if isNil(left) || isNil(right) {
+		return isNil(left) && isNil(right)
+	}
+	return reflect.DeepEqual(left, right)
+}
+
 func andStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	return boolIface(left.(bool) && right.(bool)), nil
 }
@@ -231,20 +287,69 @@ func makeLiteralStage(literal interface{}) evaluationOperator {
 	}
 }
 
-func makeFunctionStage(function ExpressionFunction) evaluationOperator {
+func makeFunctionStage(name string, function ExpressionFunction) evaluationOperator {
 
 	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
+		var ret interface{}
+		var err error
+
 		if right == nil {
-			return function()
+			ret, err = function()
+		} else {
+			switch right := right.(type) {
+			case []interface{}:
+				ret, err = function(right...)
+			default:
+				ret, err = function(right)
+			}
 		}
 
-		switch right := right.(type) {
-		case []interface{}:
-			return function(right...)
-		default:
-			return function(right)
+		if err != nil {
+			return nil, &FunctionError{Name: name, Err: err}
 		}
+		return ret, nil
+	}
+}
+
+/*
+Makes the results of [op], a call to a standard function, follow the numeric mode of the expression, as parameters do.
+The results of other functions are returned as the functions give them.
+*/
+func sanitizeResultStage(op evaluationOperator) evaluationOperator {
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+		ret, err := op(left, right, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return sanitizeFor(parameters, ret), nil
+	}
+}
+
+func makeContextFunctionStage(name string, function ContextExpressionFunction) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		var ret interface{}
+		var err error
+
+		ctx := contextOf(parameters)
+
+		if right == nil {
+			ret, err = function(ctx)
+		} else {
+			switch right := right.(type) {
+			case []interface{}:
+				ret, err = function(ctx, right...)
+			default:
+				ret, err = function(ctx, right)
+			}
+		}
+
+		if err != nil {
+			return nil, &FunctionError{Name: name, Err: err}
+		}
+		return ret, nil
 	}
 }
 
@@ -290,13 +395,19 @@ func typeConvertParams(method reflect.Value, params []reflect.Value) ([]reflect.
 	return params, nil
 }
 
-func makeAccessorStage(pair []string) evaluationOperator {
+/*
+Makes the operator of an accessor, which follows the given [pair] of names from a parameter.
+Names which are [optional] give nil for the whole accessor, rather than an error, when what they're accessed on is nil,
+or when they're missing from a map.
+*/
+func makeAccessorStage(pair []string, optional []bool) evaluationOperator {
 
 	reconstructed := strings.Join(pair, ".")
 
 	return func(left interface{}, right interface{}, parameters Parameters) (ret interface{}, err error) {
 
 		var params []reflect.Value
+		var fieldName string
 
 		value, err := parameters.Get(pair[0])
 		if err != nil {
@@ -309,7 +420,7 @@ func makeAccessorStage(pair []string) evaluationOperator {
 		defer func() {
 			if r := recover(); r != nil {
 				errorMsg := fmt.Sprintf("Failed to access '%s': %v", reconstructed, r.(string))
-				err = errors.New(errorMsg)
+				err = &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 				ret = nil
 			}
 		}()
@@ -317,6 +428,12 @@ func makeAccessorStage(pair []string) evaluationOperator {
 	LOOP:
 		for i := 1; i < len(pair); i++ {
 
+			fieldName = pair[i]
+
+			if optional != nil && optional[i] && isNil(value) {
+				return nil, nil
+			}
+
 			coreValue := reflect.ValueOf(value)
 
 			var corePtrVal reflect.Value
@@ -332,17 +449,23 @@ func makeAccessorStage(pair []string) evaluationOperator {
 
 			switch coreValue.Kind() {
 			case reflect.Struct:
+				structField, found := fieldNamingOf(parameters).lookup(coreValue.Type(), pair[i])
+				if found {
+
+					fieldValue, ok := fieldByIndex(coreValue, structField.Index)
+					if !ok {
+						errorMsg := fmt.Sprintf("Unable to access '%s' of '%s', it belongs to an embedded struct which is nil", pair[i], pair[i-1])
+						return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
+					}
+					value = fieldValue.Interface()
+					continue LOOP
+				}
+
 				// check if field is exported
 				firstCharacter := getFirstRune(pair[i])
 				if unicode.ToUpper(firstCharacter) != firstCharacter {
 					errorMsg := fmt.Sprintf("Unable to access unexported field '%s' in '%s'", pair[i], pair[i-1])
-					return nil, errors.New(errorMsg)
-				}
-
-				field = coreValue.FieldByName(pair[i])
-				if field != (reflect.Value{}) {
-					value = field.Interface()
-					continue LOOP
+					return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 				}
 
 				method = coreValue.MethodByName(pair[i])
@@ -353,6 +476,9 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				}
 			case reflect.Map:
 				field = coreValue.MapIndex(reflect.ValueOf(pair[i]))
+				if field == (reflect.Value{}) && optional != nil && optional[i] {
+					return nil, nil
+				}
 				if field != (reflect.Value{}) {
 					inter := field.Interface()
 					if inter != nil && reflect.TypeOf(inter).Kind() == reflect.Func {
@@ -363,11 +489,13 @@ func makeAccessorStage(pair []string) evaluationOperator {
 					}
 				}
 			default:
-				return nil, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct or map")
+				errorMsg := "Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct or map"
+				return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 			}
 
 			if method == (reflect.Value{}) {
-				return nil, errors.New("No method or field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+				errorMsg := "No method or field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'"
+				return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 			}
 
 			switch right := right.(type) {
@@ -389,17 +517,24 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				params = []reflect.Value{reflect.ValueOf(right)}
 			}
 
+			// methods that take a context as their first argument are given the one this expression is evaluated with.
+			if method.Type().NumIn() > 0 && method.Type().In(0) == contextType {
+				params = append([]reflect.Value{reflect.ValueOf(contextOf(parameters))}, params...)
+			}
+
 			params, err = typeConvertParams(method, params)
 
 			if err != nil {
-				return nil, errors.New("Method call failed - '" + pair[0] + "." + pair[1] + "': " + err.Error())
+				errorMsg := "Method call failed - '" + pair[0] + "." + pair[1] + "': " + err.Error()
+				return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, Err: err, message: errorMsg}
 			}
 
 			returned := method.Call(params)
 			retLength := len(returned)
 
 			if retLength == 0 {
-				return nil, errors.New("Method call '" + pair[i-1] + "." + pair[i] + "' did not return any values.")
+				errorMsg := "Method call '" + pair[i-1] + "." + pair[i] + "' did not return any values."
+				return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 			}
 
 			if retLength == 1 {
@@ -414,17 +549,18 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				err, validType := errIface.(error)
 
 				if validType && errIface != nil {
-					return returned[0].Interface(), err
+					return returned[0].Interface(), &AccessorError{Accessor: reconstructed, Field: fieldName, Err: err, message: err.Error()}
 				}
 
 				value = returned[0].Interface()
 				continue
 			}
 
-			return nil, errors.New("Method call '" + pair[0] + "." + pair[1] + "' did not return either one value, or a value and an error. Cannot interpret meaning.")
+			errorMsg := "Method call '" + pair[0] + "." + pair[1] + "' did not return either one value, or a value and an error. Cannot interpret meaning."
+			return nil, &AccessorError{Accessor: reconstructed, Field: fieldName, message: errorMsg}
 		}
 
-		value = castToFloat64(value)
+		value = sanitizeFor(parameters, value)
 		return value, nil
 	}
 }
@@ -439,25 +575,29 @@ func ensureSliceStage(op evaluationOperator) evaluationOperator {
 	}
 }
 
+/*
+Starts the array of a comma-separated list with its first two elements. Any others are added by `appendSeparatorStage`.
+*/
 func separatorStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return []interface{}{left, right}, nil
+}
 
-	var ret []interface{}
+func appendSeparatorStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return append(left.([]interface{}), right), nil
+}
 
-	switch left := left.(type) {
-	case []interface{}:
-		ret = append(left, right)
-	default:
-		ret = []interface{}{left, right}
-	}
+func singleListStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return []interface{}{left}, nil
+}
 
-	return ret, nil
+func emptyListStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return []interface{}{}, nil
 }
 
 func inStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
 	for _, value := range right.([]interface{}) {
-		value = castToFloat64(value)
-		if left == value {
+		if valuesEqual(left, castToFloat64(value)) {
 			return true, nil
 		}
 	}
@@ -486,6 +626,23 @@ func isRegexOrString(value interface{}) bool {
 	return false
 }
 
+/*
+Returns true if the given [value] is nil, or is a nil pointer, map, slice, function, channel or interface.
+*/
+func isNil(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return reflected.IsNil()
+	}
+	return false
+}
+
 func isBool(value interface{}) bool {
 	switch value.(type) {
 	case bool:
@@ -314,6 +314,11 @@ func TestNoParameterEvaluation(test *testing.T) {
 			Input:    "'2014-01-02 14:12:22' <= '2014-01-02 11:12:22'",
 			Expected: false,
 		},
+		{
+			Name:     "Date with fractional seconds",
+			Input:    "'2014-01-02T14:12:22.500000000+00:00' > '2014-01-02 14:12:22+00:00'",
+			Expected: true,
+		},
 		{
 			Name:     "Sign prefix comparison",
 			Input:    "-1 < 0",
@@ -1328,6 +1333,103 @@ func TestNilParameters(test *testing.T) {
 	}
 }
 
+/*
+Tests that missing parameters are nil when the option is set, and only then.
+*/
+func TestMissingParametersAsNil(test *testing.T) {
+
+	options := ExpressionOptions{
+		Functions:              StandardFunctionDefinitions(),
+		MissingParametersAsNil: true,
+	}
+
+	inputs := map[string]interface{}{
+		"optional ?? 0":                   0.0,
+		"optional == nil":                 true,
+		"has(optional)":                   false,
+		"has(given) && !has(optional)":    true,
+		"given + (optional ?? 1)":         3.0,
+		"optional != nil && optional > 3": false,
+	}
+
+	for input, expected := range inputs {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"given": 2})
+		if err != nil || result != expected {
+			test.Errorf("'%s' evaluated to %v (%v), expected %v", input, result, err, expected)
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("optional ?? 0")
+	_, err := expression.Evaluate(nil)
+
+	var missing *MissingParameterError
+	if !errors.As(err, &missing) {
+		test.Errorf("Expected a *MissingParameterError without the option, got '%v'", err)
+	}
+}
+
+func TestOptionalChaining(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"foo":     dummyParameterInstance,
+		"nothing": (*dummyParameter)(nil),
+		"nested":  map[string]interface{}{"empty": nil, "inner": map[string]interface{}{"value": 1}},
+	}
+
+	inputs := map[string]interface{}{
+		"nothing?.String":                        nil,
+		"nothing?.Nested.Funk == nil":            true,
+		"(nothing?.Func()) ?? 'none'":            "none",
+		"foo?.Nested?.Funk":                      "funkalicious",
+		"foo.Nil?.Value":                         nil,
+		"foo.Map?.Missing?.Value ?? 1":           1.0,
+		"nested.empty?.value":                    nil,
+		"nested?.missing ?? nested.inner?.value": 1.0,
+		"nested.inner?.value + 1":                2.0,
+	}
+
+	for input, expected := range inputs {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || result != expected {
+			test.Errorf("'%s' evaluated to %v (%v), expected %v", input, result, err, expected)
+		}
+	}
+
+	// only the names after `?.` are optional.
+	failures := []string{
+		"nothing.String",
+		"foo.Nil.Value",
+		"nested.missing?.value",
+		"foo?.Missing",
+	}
+
+	for _, input := range failures {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		var accessorError *AccessorError
+		_, err := expression.Evaluate(parameters)
+
+		if !errors.As(err, &accessorError) {
+			test.Errorf("Expected '%s' to fail with an AccessorError, got: %v", input, err)
+		}
+	}
+}
+
 /*
 Tests functionality related to using functions with a struct method receiver.
 Created to test #54.
@@ -0,0 +1,350 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of the syntax tree produced by a given expression.
+*/
+type ASTTest struct {
+	Name     string
+	Input    string
+	Expected Node
+}
+
+func TestAST(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	astTests := []ASTTest{
+
+		{
+			Name:     "Single literal",
+			Input:    "1",
+			Expected: &Literal{Value: 1.0},
+		},
+		{
+			Name:     "Single variable",
+			Input:    "[foo bar]",
+			Expected: &Variable{Name: "foo bar"},
+		},
+		{
+			Name:  "Precedence",
+			Input: "a + b * 2",
+			Expected: &BinaryOp{
+				Operator: PLUS,
+				Left:     &Variable{Name: "a"},
+				Right: &BinaryOp{
+					Operator: MULTIPLY,
+					Left:     &Variable{Name: "b"},
+					Right:    &Literal{Value: 2.0},
+				},
+			},
+		},
+		{
+			Name:  "Left associativity",
+			Input: "a - b - c",
+			Expected: &BinaryOp{
+				Operator: MINUS,
+				Left: &BinaryOp{
+					Operator: MINUS,
+					Left:     &Variable{Name: "a"},
+					Right:    &Variable{Name: "b"},
+				},
+				Right: &Variable{Name: "c"},
+			},
+		},
+		{
+			Name:  "Parenthesis",
+			Input: "(a + b) * 2",
+			Expected: &BinaryOp{
+				Operator: MULTIPLY,
+				Left: &BinaryOp{
+					Operator: PLUS,
+					Left:     &Variable{Name: "a"},
+					Right:    &Variable{Name: "b"},
+				},
+				Right: &Literal{Value: 2.0},
+			},
+		},
+		{
+			Name:  "Constants are not folded",
+			Input: "1 + 2",
+			Expected: &BinaryOp{
+				Operator: PLUS,
+				Left:     &Literal{Value: 1.0},
+				Right:    &Literal{Value: 2.0},
+			},
+		},
+		{
+			Name:  "Prefix",
+			Input: "!(a && -b > 0)",
+			Expected: &UnaryOp{
+				Operator: INVERT,
+				Operand: &BinaryOp{
+					Operator: AND,
+					Left:     &Variable{Name: "a"},
+					Right: &BinaryOp{
+						Operator: GT,
+						Left: &UnaryOp{
+							Operator: NEGATE,
+							Operand:  &Variable{Name: "b"},
+						},
+						Right: &Literal{Value: 0.0},
+					},
+				},
+			},
+		},
+		{
+			Name:  "Ternary",
+			Input: "a ? 'yes' : 'no'",
+			Expected: &Ternary{
+				Condition: &Variable{Name: "a"},
+				True:      &Literal{Value: "yes"},
+				False:     &Literal{Value: "no"},
+			},
+		},
+		{
+			Name:  "Ternary without false clause",
+			Input: "a ? 'yes'",
+			Expected: &Ternary{
+				Condition: &Variable{Name: "a"},
+				True:      &Literal{Value: "yes"},
+			},
+		},
+		{
+			Name:  "Array",
+			Input: "a in (1, 2, b)",
+			Expected: &BinaryOp{
+				Operator: IN,
+				Left:     &Variable{Name: "a"},
+				Right: &Array{Elements: []Node{
+					&Literal{Value: 1.0},
+					&Literal{Value: 2.0},
+					&Variable{Name: "b"},
+				}},
+			},
+		},
+		{
+			Name:  "Single element array",
+			Input: "a in (1)",
+			Expected: &BinaryOp{
+				Operator: IN,
+				Left:     &Variable{Name: "a"},
+				Right:    &Array{Elements: []Node{&Literal{Value: 1.0}}},
+			},
+		},
+		{
+			Name:  "List",
+			Input: "{a, {}, {1}}",
+			Expected: &List{Elements: []Node{
+				&Variable{Name: "a"},
+				&List{},
+				&List{Elements: []Node{&Literal{Value: 1.0}}},
+			}},
+		},
+		{
+			Name:  "Function",
+			Input: "max(a, 2)",
+			Expected: &FunctionCall{
+				Name: "max",
+				Arguments: []Node{
+					&Variable{Name: "a"},
+					&Literal{Value: 2.0},
+				},
+			},
+		},
+		{
+			Name:     "Function without arguments",
+			Input:    "max()",
+			Expected: &FunctionCall{Name: "max"},
+		},
+		{
+			Name:     "Accessor field",
+			Input:    "foo.Bar.Baz",
+			Expected: &Accessor{Path: []string{"foo", "Bar", "Baz"}},
+		},
+		{
+			Name:  "Accessor method",
+			Input: "foo.Func(1, a)",
+			Expected: &Accessor{
+				Path:      []string{"foo", "Func"},
+				Call:      true,
+				Arguments: []Node{&Literal{Value: 1.0}, &Variable{Name: "a"}},
+			},
+		},
+		{
+			Name:  "Index",
+			Input: "a[i + 1].Name",
+			Expected: &Index{
+				Target: &Index{
+					Target: &Variable{Name: "a"},
+					Key: &BinaryOp{
+						Operator: PLUS,
+						Left:     &Variable{Name: "i"},
+						Right:    &Literal{Value: 1.0},
+					},
+				},
+				Field: "Name",
+			},
+		},
+		{
+			Name:  "Quantifier",
+			Input: "any(items, #.price > 10)",
+			Expected: &Quantifier{
+				Name:       "any",
+				Collection: &Variable{Name: "items"},
+				Expression: &BinaryOp{
+					Operator: GT,
+					Left:     &Index{Target: &Item{}, Field: "price"},
+					Right:    &Literal{Value: 10.0},
+				},
+			},
+		},
+		{
+			Name:  "Date literal",
+			Input: "created > '2024-01-02 03:04:05'",
+			Expected: &BinaryOp{
+				Operator: GT,
+				Left:     &Variable{Name: "created"},
+				Right:    &Literal{Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)},
+			},
+		},
+		{
+			Name:  "Optional accessor",
+			Input: "foo?.Nested.Funk",
+			Expected: &Accessor{
+				Path:     []string{"foo", "Nested", "Funk"},
+				Optional: []bool{false, true, false},
+			},
+		},
+		{
+			Name:  "Accessor method without arguments",
+			Input: "foo.Func()",
+			Expected: &Accessor{
+				Path: []string{"foo", "Func"},
+				Call: true,
+			},
+		},
+	}
+
+	for _, astTest := range astTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(astTest.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", astTest.Name, err)
+			continue
+		}
+
+		actual := expression.AST()
+		if !reflect.DeepEqual(actual, astTest.Expected) {
+			test.Errorf("Test '%s' failed", astTest.Name)
+			test.Logf("Expected: %#v", astTest.Expected)
+			test.Logf("Actual: %#v", actual)
+		}
+	}
+}
+
+func TestASTAfterCleanup(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a + (1 + 2)")
+	expression.CleanupTokens()
+
+	expected := &BinaryOp{
+		Operator: PLUS,
+		Left:     &Variable{Name: "a"},
+		Right:    &Literal{Value: 3.0},
+	}
+
+	actual := expression.AST()
+	if !reflect.DeepEqual(actual, expected) {
+		test.Errorf("Expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestASTFromTokens(test *testing.T) {
+
+	tokens := []ExpressionToken{
+		{Kind: VARIABLE, Value: "foo"},
+		{Kind: COMPARATOR, Value: ">"},
+		{Kind: NUMERIC, Value: 1.0},
+	}
+
+	expression, err := NewEvaluableExpressionFromTokens(tokens)
+	if err != nil {
+		test.Fatalf("Unable to create expression: %v", err)
+	}
+
+	expected := &BinaryOp{
+		Operator: GT,
+		Left:     &Variable{Name: "foo"},
+		Right:    &Literal{Value: 1.0},
+	}
+
+	actual := expression.AST()
+	if !reflect.DeepEqual(actual, expected) {
+		test.Errorf("Expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestInspect(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a > 1 && (b || c.Enabled) ? d : 'none'")
+
+	var variables []string
+	var nodes int
+
+	Inspect(expression.AST(), func(node Node) bool {
+
+		if node == nil {
+			return false
+		}
+		nodes++
+
+		switch node := node.(type) {
+		case *Variable:
+			variables = append(variables, node.Name)
+		case *Accessor:
+			variables = append(variables, node.Path[0])
+		}
+		return true
+	})
+
+	expectedVariables := []string{"a", "b", "c", "d"}
+	if !reflect.DeepEqual(variables, expectedVariables) {
+		test.Errorf("Expected variables %v, got %v", expectedVariables, variables)
+	}
+
+	// ternary, &&, >, a, 1, ||, b, c.Enabled, d, 'none'
+	if nodes != 10 {
+		test.Errorf("Expected 10 nodes, visited %d", nodes)
+	}
+}
+
+func TestInspectSkipsChildren(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("-a * c.Field(b)")
+
+	var visited []Node
+	Inspect(expression.AST(), func(node Node) bool {
+
+		if node != nil {
+			visited = append(visited, node)
+		}
+
+		_, isBinary := node.(*BinaryOp)
+		return isBinary
+	})
+
+	// the binary operator, and both of its operands, but nothing beneath them.
+	if len(visited) != 3 {
+		test.Errorf("Expected 3 nodes to be visited, visited %d", len(visited))
+	}
+}
@@ -0,0 +1,272 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+/*
+Represents a test of the normalized text an expression is formatted as.
+*/
+type FormatTest struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+func TestFormat(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	formatTests := []FormatTest{
+
+		{
+			Name:     "Spacing",
+			Input:    "a+b  *2",
+			Expected: "a + b * 2",
+		},
+		{
+			Name:     "Redundant parenthesis",
+			Input:    "((a + (b * 2)))",
+			Expected: "a + b * 2",
+		},
+		{
+			Name:     "Needed parenthesis",
+			Input:    "(a + b) * 2",
+			Expected: "(a + b) * 2",
+		},
+		{
+			Name:     "Left associative chain",
+			Input:    "(a - b) - c",
+			Expected: "a - b - c",
+		},
+		{
+			Name:     "Right operand of same precedence",
+			Input:    "a - (b - c)",
+			Expected: "a - (b - c)",
+		},
+		{
+			Name:     "Logical precedence",
+			Input:    "(a && b) || (c && (d || e))",
+			Expected: "a && b || c && (d || e)",
+		},
+		{
+			Name:     "Comparators",
+			Input:    "(a > 1) && (b == 'x') && (c =~ '^x')",
+			Expected: "a > 1 && b == 'x' && c =~ '^x'",
+		},
+		{
+			Name:     "Prefixes",
+			Input:    "!(a) && -(b + 1) > ~c",
+			Expected: "!a && -(b + 1) > ~c",
+		},
+		{
+			Name:     "Nested prefixes",
+			Input:    "-(-a)",
+			Expected: "-(-a)",
+		},
+		{
+			Name:     "Ternary",
+			Input:    "(a > 1) ? ('yes') : 'no'",
+			Expected: "a > 1 ? 'yes' : 'no'",
+		},
+		{
+			Name:     "Nested ternary",
+			Input:    "a ? (b ? 1 : 2) : 3",
+			Expected: "a ? (b ? 1 : 2) : 3",
+		},
+		{
+			Name:     "Coalesce",
+			Input:    "(a ?? b) ?? 'none'",
+			Expected: "a ?? b ?? 'none'",
+		},
+		{
+			Name:     "Arrays",
+			Input:    "a IN (1,2 , 'three')",
+			Expected: "a in (1, 2, 'three')",
+		},
+		{
+			Name:     "Single element array",
+			Input:    "a in (1)",
+			Expected: "a in (1)",
+		},
+		{
+			Name:     "Lists",
+			Input:    "{ 1,{2} , {}} == a ? {(1, 2)} : {b}",
+			Expected: "{1, {2}, {}} == a ? {(1, 2)} : {b}",
+		},
+		{
+			Name:     "Indexes",
+			Input:    "a[ 0 ][b[1]] .C.D + [e f][(1 + g)] + 'x'[0]",
+			Expected: "a[0][b[1]].C.D + [e f][1 + g] + 'x'[0]",
+		},
+		{
+			Name:     "Indexed values",
+			Input:    "(-a)[0] + (a ? b : c)[0] + {1}[0] + max(1)[0] + (foo.Bar(1))[0]",
+			Expected: "(-a)[0] + (a ? b : c)[0] + {1}[0] + max(1)[0] + (foo.Bar(1))[0]",
+		},
+		{
+			Name:     "Nil",
+			Input:    "a == null || [nil] != nil",
+			Expected: "a == nil || [nil] != nil",
+		},
+		{
+			Name:     "Quantifiers",
+			Input:    "any( items,#.price>10 ) && map(filter(a, # in b), #[0] * 2)[0] != nil",
+			Expected: "any(items, #.price > 10) && map(filter(a, # in b), #[0] * 2)[0] != nil",
+		},
+		{
+			Name:     "Quantifier names as variables",
+			Input:    "[any] + [map]",
+			Expected: "any + map",
+		},
+		{
+			Name:     "Optional accessors",
+			Input:    "foo?.Bar.Baz?.Qux ?? foo?.Call( 1 )",
+			Expected: "foo?.Bar.Baz?.Qux ?? foo?.Call(1)",
+		},
+		{
+			Name:     "Numbers",
+			Input:    "1.50 + 0x10 + 1000000",
+			Expected: "1.5 + 16 + 1000000",
+		},
+		{
+			Name:     "Quoting",
+			Input:    `"it\'s" + 'say \"hi\"' + '\\'`,
+			Expected: `'it\'s' + 'say \"hi\"' + '\\'`,
+		},
+		{
+			Name:     "Escaped variables",
+			Input:    "[foo bar] + [true] + [baz]",
+			Expected: "[foo bar] + [true] + baz",
+		},
+		{
+			Name:     "Functions",
+			Input:    "max( a,(b + 1) ) * max()",
+			Expected: "max(a, b + 1) * max()",
+		},
+		{
+			Name:     "Accessors",
+			Input:    "foo.Bar + foo.Func( 1 , 2 )",
+			Expected: "foo.Bar + foo.Func(1, 2)",
+		},
+		{
+			Name:     "Accessor call followed by an operator",
+			Input:    "(foo.Func(1)) + 2",
+			Expected: "(foo.Func(1)) + 2",
+		},
+		{
+			Name:     "Constants are kept",
+			Input:    "1 + 2",
+			Expected: "1 + 2",
+		},
+		{
+			Name:     "Date literal",
+			Input:    "created > '2024-01-02 03:04:05+00:00'",
+			Expected: "created > '2024-01-02 03:04:05+00:00'",
+		},
+		{
+			Name:     "Date literal with fractional seconds",
+			Input:    "created > '2024-01-02T03:04:05.250000000+00:00'",
+			Expected: "created > '2024-01-02T03:04:05.250000000+00:00'",
+		},
+		{
+			Name:     "Date literal in a single element array",
+			Input:    "created in ('2024-01-02 03:04:05+00:00')",
+			Expected: "created in ('2024-01-02 03:04:05+00:00')",
+		},
+	}
+
+	for _, formatTest := range formatTests {
+
+		actual, err := FormatExpression(formatTest.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to format: %v", formatTest.Name, err)
+			continue
+		}
+
+		if actual != formatTest.Expected {
+			test.Errorf("Test '%s' failed", formatTest.Name)
+			test.Logf("Expected: %s", formatTest.Expected)
+			test.Logf("Actual: %s", actual)
+			continue
+		}
+
+		// formatting should be stable.
+		again, err := FormatExpression(actual, functions)
+		if err != nil || again != actual {
+			test.Errorf("Test '%s' was not stable, formatted '%s' as '%s' (%v)", formatTest.Name, actual, again, err)
+		}
+	}
+}
+
+func TestFormatPreservesEvaluation(test *testing.T) {
+
+	inputs := []string{
+		"10 - (4 - 3) - 2",
+		"2 ** (3 ** 2)",
+		"(2 + 3) * (4 - 1) / -(1 + 1)",
+		"true ? (false ? 1 : 2) : 3",
+		"(1 < 2) == (3 > 4)",
+		"(5 | 2) & (1 << 3) >> 1",
+		"'2024-01-02T03:04:05.250000000+00:00' > '2024-01-02 03:04:05+00:00'",
+	}
+
+	for _, input := range inputs {
+
+		original, _ := NewEvaluableExpression(input)
+		formatted, err := original.Format()
+		if err != nil {
+			test.Errorf("Unable to format '%s': %v", input, err)
+			continue
+		}
+
+		reparsed, err := NewEvaluableExpression(formatted)
+		if err != nil {
+			test.Errorf("Unable to parse '%s' (formatted from '%s'): %v", formatted, input, err)
+			continue
+		}
+
+		expected, _ := original.Evaluate(nil)
+		actual, _ := reparsed.Evaluate(nil)
+		if expected != actual {
+			test.Errorf("'%s' evaluated to %v, but its formatted form '%s' evaluated to %v", input, expected, formatted, actual)
+		}
+	}
+}
+
+func TestFormatFromTokens(test *testing.T) {
+
+	tokens := []ExpressionToken{
+		{Kind: VARIABLE, Value: "foo bar"},
+		{Kind: COMPARATOR, Value: ">="},
+		{Kind: NUMERIC, Value: 1.0},
+	}
+
+	expression, _ := NewEvaluableExpressionFromTokens(tokens)
+
+	actual, err := expression.Format()
+	if err != nil {
+		test.Fatalf("Unable to format expression: %v", err)
+	}
+
+	if actual != "[foo bar] >= 1" {
+		test.Errorf("Expected '[foo bar] >= 1', got '%s'", actual)
+	}
+
+	tokens = []ExpressionToken{
+		{Kind: FUNCTION, Value: ExpressionFunction(func(arguments ...interface{}) (interface{}, error) { return nil, nil })},
+		{Kind: CLAUSE},
+		{Kind: CLAUSE_CLOSE},
+	}
+
+	expression, _ = NewEvaluableExpressionFromTokens(tokens)
+
+	_, err = expression.Format()
+	if err == nil {
+		test.Errorf("Expected an error formatting a function without a name")
+	}
+}
@@ -1,8 +1,45 @@
 package govaluate
 
+import (
+	"context"
+)
+
 /*
 Represents a function that can be called from within an expression.
 This method must return an error if, for any reason, it is unable to produce exactly one unambiguous result.
 An error returned will halt execution of the expression.
 */
 type ExpressionFunction func(arguments ...interface{}) (interface{}, error)
+
+/*
+Represents a function that can be called from within an expression, and which is also given the context
+that was passed to `EvalContext` (or `context.Background()` when evaluated through `Eval`).
+Long-running functions should watch [ctx] and abort with its error when it is cancelled.
+*/
+type ContextExpressionFunction func(ctx context.Context, arguments ...interface{}) (interface{}, error)
+
+/*
+Describes a function that can be called from within an expression, along with what's known about it ahead of time.
+Given in `ExpressionOptions.Functions`, a definition is used in four ways:
+
+  - Calls with too few or too many arguments for its signature are rejected when the expression is parsed.
+  - `EvaluableExpression.TypeCheck` checks the types of its arguments, and knows the type it returns.
+  - If [Pure] is true, `EvaluableExpression.PartialEval` runs it when all of its arguments are known.
+  - If [KeepArrays] is true, an array given as its only argument is passed to it as that one argument.
+
+Only one of [Function] and [ContextFunction] should be set. If neither is, the definition describes the function of the same name
+given to `NewEvaluableExpressionWithOptions`, so that signatures can be added to an existing map of functions.
+*/
+type FunctionDefinition struct {
+	Function        ExpressionFunction
+	ContextFunction ContextExpressionFunction
+
+	FunctionSignature
+
+	// whether the function always returns the same result for the same arguments, and has no side effects.
+	Pure bool
+
+	// whether an array given as the function's only argument is passed as it is, as functions which take a collection need.
+	// Otherwise the array is spread into the function's arguments, as it is for functions without a definition.
+	KeepArrays bool
+}
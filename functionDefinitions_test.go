@@ -0,0 +1,317 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+/*
+Represents a test of parsing an expression which calls functions with definitions.
+[Column] is where the expected argument count error points; if it's 0, the expression is expected to parse.
+*/
+type FunctionDefinitionTest struct {
+	Name   string
+	Input  string
+	Column int
+}
+
+func TestFunctionDefinitionArity(test *testing.T) {
+
+	anything := func(arguments ...interface{}) (interface{}, error) {
+		return len(arguments), nil
+	}
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"max": {
+				Function: anything,
+				FunctionSignature: FunctionSignature{
+					Arguments:    []ValueType{NumberType},
+					MinArguments: 2,
+					MaxArguments: -1,
+					Returns:      NumberType,
+				},
+			},
+			"contains": {
+				Function: anything,
+				FunctionSignature: FunctionSignature{
+					Arguments: []ValueType{StringType, StringType},
+					Returns:   BoolType,
+				},
+			},
+			"pad": {
+				Function: anything,
+				FunctionSignature: FunctionSignature{
+					Arguments:    []ValueType{StringType, NumberType, StringType},
+					MinArguments: 2,
+					MaxArguments: 3,
+				},
+			},
+			"now": {
+				Function: anything,
+				FunctionSignature: FunctionSignature{
+					Arguments: []ValueType{},
+				},
+			},
+			"any": {
+				Function: anything,
+			},
+		},
+	}
+
+	definitionTests := []FunctionDefinitionTest{
+
+		{
+			Name:  "Unlimited arguments",
+			Input: "max(1, 2, 3, 4)",
+		},
+		{
+			Name:   "Too few of unlimited arguments",
+			Input:  "max(1)",
+			Column: 1,
+		},
+		{
+			Name:  "Exact arguments",
+			Input: "contains('abc', 'b')",
+		},
+		{
+			Name:   "No arguments",
+			Input:  "1 + 2 > 0 && contains()",
+			Column: 14,
+		},
+		{
+			Name:   "Too many arguments",
+			Input:  "contains('a', 'b', 'c')",
+			Column: 1,
+		},
+		{
+			Name:  "Optional arguments",
+			Input: "pad('a', 3) == pad('a', 3, ' ')",
+		},
+		{
+			Name:   "Too many optional arguments",
+			Input:  "pad('a', 3, ' ', ' ')",
+			Column: 1,
+		},
+		{
+			Name:  "No arguments allowed",
+			Input: "now()",
+		},
+		{
+			Name:   "Arguments given to a function which takes none",
+			Input:  "now(1)",
+			Column: 1,
+		},
+		{
+			Name:  "No signature",
+			Input: "any() + any(1, 2, 3)",
+		},
+		{
+			Name:   "Nested calls",
+			Input:  "max(1, max(2))",
+			Column: 8,
+		},
+	}
+
+	for _, definitionTest := range definitionTests {
+
+		_, err := NewEvaluableExpressionWithOptions(definitionTest.Input, nil, options)
+
+		if definitionTest.Column == 0 {
+			if err != nil {
+				test.Errorf("Test '%s' failed: %v", definitionTest.Name, err)
+			}
+			continue
+		}
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			test.Errorf("Test '%s' failed: expected a ParseError, got '%v'", definitionTest.Name, err)
+			continue
+		}
+
+		if parseError.Code != PARSE_ARGUMENT_COUNT || parseError.Column != definitionTest.Column {
+			test.Errorf("Test '%s' failed: expected %v at column %d, got %v at column %d (%s)",
+				definitionTest.Name, PARSE_ARGUMENT_COUNT, definitionTest.Column, parseError.Code, parseError.Column, parseError.Message)
+		}
+	}
+}
+
+func TestFunctionDefinitionWithoutFunction(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"double": {
+				FunctionSignature: FunctionSignature{
+					Arguments: []ValueType{NumberType},
+					Returns:   NumberType,
+				},
+			},
+		},
+	}
+
+	_, err := NewEvaluableExpressionWithOptions("double(1, 2)", functions, options)
+	if err == nil {
+		test.Errorf("Expected an argument count error for a plain function with a definition")
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("double(2)", functions, options)
+	if err != nil {
+		test.Fatalf("Unable to parse: %v", err)
+	}
+
+	result, err := expression.Evaluate(nil)
+	if err != nil || result != 4.0 {
+		test.Errorf("Expected 4, got %v (%v)", result, err)
+	}
+}
+
+func TestFunctionDefinitionContextFunction(test *testing.T) {
+
+	type key struct{}
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"value": {
+				ContextFunction: func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+					return ctx.Value(key{}), nil
+				},
+			},
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("value()", nil, options)
+	if err != nil {
+		test.Fatalf("Unable to parse: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), key{}, "found")
+
+	result, err := expression.EvalContext(ctx, nil)
+	if err != nil || result != "found" {
+		test.Errorf("Expected 'found', got %v (%v)", result, err)
+	}
+}
+
+func TestFunctionDefinitionTypes(test *testing.T) {
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"length": {
+				Function: func(arguments ...interface{}) (interface{}, error) {
+					return float64(len(arguments[0].(string))), nil
+				},
+				FunctionSignature: FunctionSignature{
+					Arguments: []ValueType{StringType},
+					Returns:   NumberType,
+				},
+			},
+		},
+	}
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"name":  StringType,
+			"count": NumberType,
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("length(name) > 3", nil, options)
+
+	err := expression.TypeCheck(schema)
+	if err != nil {
+		test.Errorf("Expected 'length(name) > 3' to type check, got: %v", err)
+	}
+
+	expression, _ = NewEvaluableExpressionWithOptions("length(count) && length(name) =~ 'a'", nil, options)
+
+	var checkErrors TypeCheckErrors
+	err = expression.TypeCheck(schema)
+
+	if !errors.As(err, &checkErrors) || len(checkErrors) != 3 {
+		test.Errorf("Expected three type errors, got: %v", err)
+	}
+}
+
+func TestFunctionDefinitionPurity(test *testing.T) {
+
+	calls := 0
+	function := func(arguments ...interface{}) (interface{}, error) {
+		calls++
+		return arguments[0].(float64) + 1, nil
+	}
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"pure": {
+				Function: function,
+				Pure:     true,
+			},
+			"impure": {
+				Function: function,
+			},
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("pure(a) + impure(a) + pure(b)", nil, options)
+
+	residual, err := expression.PartialEval(MapParameters{"a": 1})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	expected := "2 + impure(1) + pure(b)"
+	if residual.String() != expected {
+		test.Errorf("Expected '%s', got '%s'", expected, residual.String())
+	}
+
+	if calls != 1 {
+		test.Errorf("Expected the pure function to be called once, but functions were called %d times", calls)
+	}
+}
+
+func TestFunctionDefinitionKeepArrays(test *testing.T) {
+
+	count := func(arguments ...interface{}) (interface{}, error) {
+		return float64(len(arguments)), nil
+	}
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"kept": {
+				Function:   count,
+				KeepArrays: true,
+				Pure:       true,
+			},
+			"spread": {
+				Function: count,
+			},
+		},
+	}
+
+	parameters := map[string]interface{}{"a": []interface{}{1, 2, 3}}
+
+	expression, _ := NewEvaluableExpressionWithOptions("kept(a) * 10 + spread(a) + kept(a, a) * 100", nil, options)
+
+	result, err := expression.Evaluate(parameters)
+	if err != nil || result != 213.0 {
+		test.Errorf("Expected 213, got %v: %v", result, err)
+	}
+
+	residual, err := expression.PartialEval(MapParameters(parameters))
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	result, err = residual.Evaluate(nil)
+	if err != nil || result != 213.0 {
+		test.Errorf("Expected the residual '%s' to give 213, got %v: %v", residual.String(), result, err)
+	}
+}
@@ -0,0 +1,182 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
+/*
+Looks up the [right] key in the [left] value, which may be a slice, array, string, map or struct (or a pointer to one).
+Slices, arrays and strings are indexed by whole numbers, strings by character rather than by byte.
+Maps are indexed by keys which are (or can be converted to) their key type, and structs by the name of an exported field (see `fieldNaming`).
+*/
+func indexStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	value, err := indexValue(left, right, fieldNamingOf(parameters))
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeFor(parameters, value), nil
+}
+
+func indexValue(container interface{}, key interface{}, fields fieldNaming) (interface{}, error) {
+
+	value := reflect.ValueOf(container)
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+
+		if value.IsNil() {
+			return nil, &IndexError{Key: key, message: fmt.Sprintf("Unable to index '%v', it is nil", container)}
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+
+	case reflect.Slice, reflect.Array, reflect.String:
+
+		position, ok := wholeNumber(key)
+		if !ok {
+			return nil, newIndexTypeError(container, key, fmt.Sprintf("Unable to index '%v' with '%v', it is not a whole number", container, formatIfDecimal(key)))
+		}
+
+		if value.Kind() == reflect.String {
+
+			characters := []rune(value.String())
+			if position < 0 || position >= int64(len(characters)) {
+				return nil, outOfRangeError(container, key, len(characters))
+			}
+			return string(characters[position]), nil
+		}
+
+		if position < 0 || position >= int64(value.Len()) {
+			return nil, outOfRangeError(container, key, value.Len())
+		}
+		return value.Index(int(position)).Interface(), nil
+
+	case reflect.Map:
+
+		mapKey, ok := convertMapKey(key, value.Type().Key())
+		if !ok {
+			return nil, newIndexTypeError(container, key, fmt.Sprintf("Unable to index '%v' with '%v', it cannot be used as a key of %s", container, formatIfDecimal(key), value.Type()))
+		}
+
+		element := value.MapIndex(mapKey)
+		if !element.IsValid() {
+			return nil, &IndexError{Key: key, message: fmt.Sprintf("No key '%v' present in '%v'", formatIfDecimal(key), container)}
+		}
+		return element.Interface(), nil
+
+	case reflect.Struct:
+
+		name, ok := key.(string)
+		if !ok {
+			return nil, newIndexTypeError(container, key, fmt.Sprintf("Unable to index '%v' with '%v', fields are indexed by name", container, formatIfDecimal(key)))
+		}
+
+		field, found := fields.lookup(value.Type(), name)
+		if found {
+
+			fieldValue, ok := fieldByIndex(value, field.Index)
+			if !ok {
+				return nil, &IndexError{Key: key, message: fmt.Sprintf("Unable to index '%v' with '%s', it belongs to an embedded struct which is nil", container, name)}
+			}
+			return fieldValue.Interface(), nil
+		}
+
+		firstCharacter := getFirstRune(name)
+		if unicode.ToUpper(firstCharacter) != firstCharacter {
+			return nil, &IndexError{Key: key, message: fmt.Sprintf("Unable to access unexported field '%s' of '%v'", name, container)}
+		}
+		return nil, &IndexError{Key: key, message: fmt.Sprintf("No field '%s' present in '%v'", name, container)}
+	}
+
+	return nil, newIndexTypeError(container, key, fmt.Sprintf("Unable to index '%v', it is not an array, map, string or struct", container))
+}
+
+/*
+Returns the given [key] as a value of the given map [keyType], if it is one, or is a number or string that can be converted to one without losing anything.
+*/
+func convertMapKey(key interface{}, keyType reflect.Type) (reflect.Value, bool) {
+
+	if key == nil {
+		return reflect.Value{}, false
+	}
+
+	value := reflect.ValueOf(key)
+	if value.Type().AssignableTo(keyType) {
+		return value, true
+	}
+
+	ret := reflect.New(keyType).Elem()
+
+	switch keyType.Kind() {
+
+	case reflect.String:
+		if value.Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		ret.SetString(value.String())
+		return ret, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := wholeNumber(key)
+		if !ok || ret.OverflowInt(number) {
+			return reflect.Value{}, false
+		}
+		ret.SetInt(number)
+		return ret, true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, ok := wholeNumber(key)
+		if !ok || number < 0 || ret.OverflowUint(uint64(number)) {
+			return reflect.Value{}, false
+		}
+		ret.SetUint(uint64(number))
+		return ret, true
+
+	case reflect.Float32, reflect.Float64:
+		decimal := asDecimal(key)
+		if decimal == nil {
+			return reflect.Value{}, false
+		}
+
+		number, _ := decimal.Float64()
+		if ret.OverflowFloat(number) {
+			return reflect.Value{}, false
+		}
+		ret.SetFloat(number)
+		return ret, true
+	}
+
+	return reflect.Value{}, false
+}
+
+/*
+Returns the given [value] as an int64, if it's a whole number (in any numeric mode) which fits in one.
+*/
+func wholeNumber(value interface{}) (int64, bool) {
+
+	decimal := asDecimal(value)
+	if decimal == nil || !decimal.IsInt() || !decimal.Num().IsInt64() {
+		return 0, false
+	}
+	return decimal.Num().Int64(), true
+}
+
+func outOfRangeError(container interface{}, key interface{}, length int) error {
+	return &IndexError{Key: key, message: fmt.Sprintf("Index %v is out of range of '%v', which has %d elements", formatIfDecimal(key), container, length)}
+}
+
+func newIndexTypeError(container interface{}, key interface{}, message string) error {
+
+	return &TypeMismatchError{
+		Operator:  INDEX_ACCESS,
+		Left:      container,
+		Right:     key,
+		LeftType:  reflect.TypeOf(container),
+		RightType: reflect.TypeOf(key),
+		message:   message,
+	}
+}
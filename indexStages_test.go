@@ -0,0 +1,227 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+/*
+Represents a test of evaluating an expression which indexes arrays, maps, strings and structs.
+*/
+type IndexStageTest struct {
+	Name     string
+	Input    string
+	Numerics NumericMode
+	Expected interface{}
+}
+
+func TestIndexStages(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"items":   []interface{}{1, "two", []interface{}{3, 4}},
+		"i":       1,
+		"headers": map[string]string{"X-Request-Id": "42"},
+		"header":  "X-Request-Id",
+		"ids":     map[int]string{7: "seven"},
+		"foo":     dummyParameterInstance,
+		"fooptr":  &dummyParameterInstance,
+		"users":   []dummyParameter{dummyParameterInstance},
+		"word":    "héllo",
+	}
+
+	indexTests := []IndexStageTest{
+
+		{
+			Name:     "Array index",
+			Input:    "items[0]",
+			Expected: 1.0,
+		},
+		{
+			Name:     "Array index by parameter",
+			Input:    "items[i]",
+			Expected: "two",
+		},
+		{
+			Name:     "Array index by expression",
+			Input:    "items[i * 2 - 1]",
+			Expected: "two",
+		},
+		{
+			Name:     "Chained indexes",
+			Input:    "items[2][1]",
+			Expected: 4.0,
+		},
+		{
+			Name:     "Nested indexes",
+			Input:    "items[items[0]]",
+			Expected: "two",
+		},
+		{
+			Name:     "Map index",
+			Input:    "headers['X-Request-Id']",
+			Expected: "42",
+		},
+		{
+			Name:     "Map index by parameter",
+			Input:    "headers[header] == '42'",
+			Expected: true,
+		},
+		{
+			Name:     "Map with integer keys",
+			Input:    "ids[7]",
+			Expected: "seven",
+		},
+		{
+			Name:     "Map within a struct",
+			Input:    "foo.Map['IntArray'][2]",
+			Expected: 3.0,
+		},
+		{
+			Name:     "Field after an index",
+			Input:    "users[0].Nested.Funk",
+			Expected: "funkalicious",
+		},
+		{
+			Name:     "Map key after an index",
+			Input:    "users[0].Map.String",
+			Expected: "string!",
+		},
+		{
+			Name:     "Struct field by name",
+			Input:    "foo['String'] + fooptr['String']",
+			Expected: "string!string!",
+		},
+		{
+			Name:     "String index",
+			Input:    "word[1]",
+			Expected: "é",
+		},
+		{
+			Name:     "String literal index",
+			Input:    "'abc'[2]",
+			Expected: "c",
+		},
+		{
+			Name:     "List literal index",
+			Input:    "{'a', 'b'}[1]",
+			Expected: "b",
+		},
+		{
+			Name:     "Parenthesized index",
+			Input:    "(i > 0 ? items : {})[0]",
+			Expected: 1.0,
+		},
+		{
+			Name:     "Negated index",
+			Input:    "-items[0] + 2 ** items[0]",
+			Expected: 1.0,
+		},
+		{
+			Name:     "Escaped variable",
+			Input:    "[items][0]",
+			Expected: 1.0,
+		},
+		{
+			Name:     "Membership",
+			Input:    "items[1] in {'one', 'two'}",
+			Expected: true,
+		},
+		{
+			Name:     "Integer index",
+			Input:    "items[i] + items[2][0]",
+			Numerics: INTEGER_NUMERICS,
+			Expected: "two3",
+		},
+		{
+			Name:     "Integer element",
+			Input:    "items[0] + ids[7.0]",
+			Numerics: INTEGER_NUMERICS,
+			Expected: "1seven",
+		},
+		{
+			Name:     "Decimal index",
+			Input:    "items[0.5 * 2]",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: "two",
+		},
+	}
+
+	for _, indexTest := range indexTests {
+
+		options := ExpressionOptions{Numerics: indexTest.Numerics}
+
+		expression, err := NewEvaluableExpressionWithOptions(indexTest.Input, nil, options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", indexTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", indexTest.Name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, indexTest.Expected) {
+			test.Errorf("Test '%s' failed: expected %v (%T), got %v (%T)", indexTest.Name, indexTest.Expected, indexTest.Expected, result, result)
+		}
+	}
+}
+
+func TestIndexStageFailures(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"items":   []interface{}{1, 2},
+		"headers": map[string]string{"a": "b"},
+		"ids":     map[int]string{1: "one"},
+		"foo":     dummyParameterInstance,
+		"nothing": (*dummyParameter)(nil),
+		"count":   3,
+	}
+
+	missing := []string{
+		"items[2]",
+		"items[-1]",
+		"headers['b']",
+		"'abc'[3]",
+		"foo['Missing']",
+		"foo['private']",
+		"nothing['String']",
+	}
+
+	for _, input := range missing {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		var indexError *IndexError
+		_, err := expression.Evaluate(parameters)
+
+		if !errors.As(err, &indexError) {
+			test.Errorf("Expected '%s' to fail with an IndexError, got: %v", input, err)
+		}
+	}
+
+	mismatched := []string{
+		"items['a']",
+		"items[0.5]",
+		"headers[1]",
+		"ids['one']",
+		"ids[1.5]",
+		"foo[1]",
+		"count[0]",
+		"items[0][0]",
+	}
+
+	for _, input := range mismatched {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		var typeError *TypeMismatchError
+		_, err := expression.Evaluate(parameters)
+
+		if !errors.As(err, &typeError) || typeError.Operator != INDEX_ACCESS {
+			test.Errorf("Expected '%s' to fail with a TypeMismatchError, got: %v", input, err)
+		}
+	}
+}
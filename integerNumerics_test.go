@@ -0,0 +1,237 @@
+package govaluate
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+Represents a test of expression evaluation with INTEGER_NUMERICS.
+*/
+type IntegerEvaluationTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestIntegerNumerics(test *testing.T) {
+
+	evaluationTests := []IntegerEvaluationTest{
+		{
+			Name:     "Integer literal",
+			Input:    "9007199254740993",
+			Expected: int64(9007199254740993),
+		},
+		{
+			Name:     "Float literal",
+			Input:    "1.5",
+			Expected: 1.5,
+		},
+		{
+			Name:     "Literal too large for int64",
+			Input:    "18446744073709551615",
+			Expected: uint64(math.MaxUint64),
+		},
+		{
+			Name:     "Literal too large for uint64",
+			Input:    "18446744073709551616",
+			Expected: 18446744073709551616.0,
+		},
+		{
+			Name:     "Hex literal",
+			Input:    "0xFFFFFFFFFFFFFFFF",
+			Expected: uint64(math.MaxUint64),
+		},
+		{
+			Name:     "Addition above 2^53",
+			Input:    "9007199254740992 + 1",
+			Expected: int64(9007199254740993),
+		},
+		{
+			Name:       "Parameters above 2^53",
+			Input:      "id + 2",
+			Parameters: map[string]interface{}{"id": uint64(9007199254740993)},
+			Expected:   int64(9007199254740995),
+		},
+		{
+			Name:       "Comparison of IDs above 2^53",
+			Input:      "a == b",
+			Parameters: map[string]interface{}{"a": int64(9007199254740993), "b": int32(0)},
+			Expected:   false,
+		},
+		{
+			Name:       "Comparison of IDs which differ only above 2^53",
+			Input:      "a < b",
+			Parameters: map[string]interface{}{"a": int64(9007199254740992), "b": uint64(9007199254740993)},
+			Expected:   true,
+		},
+		{
+			Name:     "Integer compared with float",
+			Input:    "1 == 1.0",
+			Expected: true,
+		},
+		{
+			Name:     "Integer mixed with float",
+			Input:    "1 + 0.5",
+			Expected: 1.5,
+		},
+		{
+			Name:     "Overflow falls back to float",
+			Input:    "9223372036854775807 * 4",
+			Expected: 36893488147419103228.0,
+		},
+		{
+			Name:     "Overflow into uint64",
+			Input:    "9223372036854775807 + 1",
+			Expected: uint64(9223372036854775808),
+		},
+		{
+			Name:     "Subtraction below zero",
+			Input:    "1 - 2",
+			Expected: int64(-1),
+		},
+		{
+			Name:     "Even division",
+			Input:    "10 / 2",
+			Expected: int64(5),
+		},
+		{
+			Name:     "Uneven division",
+			Input:    "7 / 2",
+			Expected: 3.5,
+		},
+		{
+			Name:     "Modulus",
+			Input:    "-7 % 3",
+			Expected: int64(-1),
+		},
+		{
+			Name:     "Exponent",
+			Input:    "2 ** 62",
+			Expected: int64(4611686018427387904),
+		},
+		{
+			Name:     "Negative exponent",
+			Input:    "2 ** -1",
+			Expected: 0.5,
+		},
+		{
+			Name:       "Bitwise AND above 2^53",
+			Input:      "mask & 0xFF",
+			Parameters: map[string]interface{}{"mask": uint64(0xFFFFFFFFFFFFFFFF)},
+			Expected:   int64(0xFF),
+		},
+		{
+			Name:     "Bitwise OR",
+			Input:    "100 | 50",
+			Expected: int64(118),
+		},
+		{
+			Name:     "Bitwise XOR",
+			Input:    "100 ^ 50",
+			Expected: int64(86),
+		},
+		{
+			Name:     "Bitwise NOT",
+			Input:    "~10",
+			Expected: int64(-11),
+		},
+		{
+			Name:     "Shift left into uint64",
+			Input:    "1 << 63",
+			Expected: uint64(1) << 63,
+		},
+		{
+			Name:     "Shift zero left",
+			Input:    "0 << 200",
+			Expected: int64(0),
+		},
+		{
+			Name:     "Shift right",
+			Input:    "-8 >> 1",
+			Expected: int64(-4),
+		},
+		{
+			Name:       "Negation of minimum int64",
+			Input:      "-value",
+			Parameters: map[string]interface{}{"value": int64(math.MinInt64)},
+			Expected:   uint64(9223372036854775808),
+		},
+		{
+			Name:       "Integer membership",
+			Input:      "id in (1, 2, 9007199254740993)",
+			Parameters: map[string]interface{}{"id": 9007199254740993},
+			Expected:   true,
+		},
+		{
+			Name:       "Integer membership of a parameter array",
+			Input:      "id in ids",
+			Parameters: map[string]interface{}{"id": 3, "ids": []interface{}{uint8(1), int16(3)}},
+			Expected:   true,
+		},
+		{
+			Name:       "Integer accessor",
+			Input:      "foo.Int + 1",
+			Parameters: map[string]interface{}{"foo": dummyParameterInstance},
+			Expected:   int64(102),
+		},
+		{
+			Name:     "String concat",
+			Input:    "'id-' + 15",
+			Expected: "id-15",
+		},
+	}
+
+	for _, evaluationTest := range evaluationTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(evaluationTest.Input, nil, ExpressionOptions{Numerics: INTEGER_NUMERICS})
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: '%s'", evaluationTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(evaluationTest.Parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: '%s'", evaluationTest.Name, err)
+			continue
+		}
+
+		if result != evaluationTest.Expected {
+			test.Errorf("Test '%s' failed: expected '%v' (%T), got '%v' (%T)", evaluationTest.Name,
+				evaluationTest.Expected, evaluationTest.Expected, result, result)
+		}
+	}
+}
+
+func TestIntegerNumericsTyping(test *testing.T) {
+
+	expression, err := NewEvaluableExpressionWithOptions("number + bool", nil, ExpressionOptions{Numerics: INTEGER_NUMERICS})
+	if err != nil {
+		test.Fatalf("Unable to parse expression: %v", err)
+	}
+
+	_, err = expression.Evaluate(EVALUATION_FAILURE_PARAMETERS)
+	if err == nil {
+		test.Errorf("Expected a type error when adding a number to a bool")
+	}
+}
+
+func TestIntegerShiftOverflow(test *testing.T) {
+
+	options := ExpressionOptions{Numerics: INTEGER_NUMERICS}
+
+	for _, input := range []string{"1 << 200", "x << 129", "-1 << 18446744073709551615"} {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"x": 3})
+		if err == nil {
+			test.Errorf("Expected '%s' to overflow, got %v", input, result)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+/*
+Operators which are used in place of those in `stageSymbolMap` when an expression uses INTEGER_NUMERICS.
+Integers are always int64, or uint64 when too large for an int64 (see `castToInteger`).
+Whenever a float64 is involved, or an integer result can't be represented, these fall back to float64.
+*/
+var integerStageSymbolMap = map[OperatorSymbol]evaluationOperator{
+	EQ:             integerEqualStage,
+	NEQ:            integerNotEqualStage,
+	GT:             integerGtStage,
+	LT:             integerLtStage,
+	GTE:            integerGteStage,
+	LTE:            integerLteStage,
+	IN:             integerInStage,
+	BITWISE_OR:     integerBitwiseOrStage,
+	BITWISE_AND:    integerBitwiseAndStage,
+	BITWISE_XOR:    integerBitwiseXORStage,
+	BITWISE_LSHIFT: integerLeftShiftStage,
+	BITWISE_RSHIFT: integerRightShiftStage,
+	PLUS:           integerAddStage,
+	MINUS:          integerSubtractStage,
+	MULTIPLY:       integerMultiplyStage,
+	DIVIDE:         integerDivideStage,
+	MODULUS:        integerModulusStage,
+	EXPONENT:       integerExponentStage,
+	NEGATE:         integerNegateStage,
+	BITWISE_NOT:    integerBitwiseNotStage,
+}
+
+func integerAddStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	// string concat if either are strings
+	if isString(left) || isString(right) {
+		return fmt.Sprintf("%v%v", left, right), nil
+	}
+
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			ret := l + r
+			return ret, (ret > l) == (r > 0)
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.Add(l, r), true
+		},
+		func(l, r float64) float64 {
+			return l + r
+		},
+	), nil
+}
+
+func integerSubtractStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			ret := l - r
+			return ret, (ret < l) == (r > 0)
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.Sub(l, r), true
+		},
+		func(l, r float64) float64 {
+			return l - r
+		},
+	), nil
+}
+
+func integerMultiplyStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			if l == 0 || r == 0 {
+				return 0, true
+			}
+			if (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64) {
+				return 0, false
+			}
+			ret := l * r
+			return ret, ret/r == l
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.Mul(l, r), true
+		},
+		func(l, r float64) float64 {
+			return l * r
+		},
+	), nil
+}
+
+func integerDivideStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	// integer division only stays integral if it divides evenly, otherwise `7 / 2` is 3.5 just as it would be with floats.
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			if r == 0 || (l == math.MinInt64 && r == -1) || l%r != 0 {
+				return 0, false
+			}
+			return l / r, true
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			if r.Sign() == 0 {
+				return nil, false
+			}
+			quotient, remainder := new(big.Int).QuoRem(l, r, new(big.Int))
+			return quotient, remainder.Sign() == 0
+		},
+		func(l, r float64) float64 {
+			return l / r
+		},
+	), nil
+}
+
+func integerModulusStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			if r == 0 {
+				return 0, false
+			}
+			return l % r, true
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			if r.Sign() == 0 {
+				return nil, false
+			}
+			return l.Rem(l, r), true
+		},
+		math.Mod,
+	), nil
+}
+
+func integerExponentStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		nil,
+		func(l, r *big.Int) (*big.Int, bool) {
+
+			// negative exponents are fractions, and large ones can't fit in 64 bits anyway (unless the base is -1, 0 or 1).
+			if r.Sign() < 0 || (r.Cmp(big.NewInt(64)) > 0 && l.CmpAbs(big.NewInt(1)) > 0) {
+				return nil, false
+			}
+			return l.Exp(l, r, nil), true
+		},
+		math.Pow,
+	), nil
+}
+
+func integerBitwiseOrStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			return l | r, true
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.Or(l, r), true
+		},
+		func(l, r float64) float64 {
+			return float64(int64(l) | int64(r))
+		},
+	), nil
+}
+
+func integerBitwiseAndStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			return l & r, true
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.And(l, r), true
+		},
+		func(l, r float64) float64 {
+			return float64(int64(l) & int64(r))
+		},
+	), nil
+}
+
+func integerBitwiseXORStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		func(l, r int64) (int64, bool) {
+			return l ^ r, true
+		},
+		func(l, r *big.Int) (*big.Int, bool) {
+			return l.Xor(l, r), true
+		},
+		func(l, r float64) float64 {
+			return float64(int64(l) ^ int64(r))
+		},
+	), nil
+}
+
+func integerLeftShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	// integers shifted this far aren't kept, even as floats, so they're errors rather than silently becoming zero.
+	if isInteger(left) && isInteger(right) && asBigInt(left).Sign() != 0 && asBigInt(right).Cmp(big.NewInt(128)) > 0 {
+		return nil, fmt.Errorf("Unable to shift %v left by %v bits, the result overflows", left, right)
+	}
+
+	return integerOperation(left, right,
+		nil,
+		func(l, r *big.Int) (*big.Int, bool) {
+			if r.Sign() < 0 {
+				return nil, false
+			}
+			if l.Sign() == 0 {
+				return l, true
+			}
+			return l.Lsh(l, uint(r.Uint64())), true
+		},
+		func(l, r float64) float64 {
+			return float64(uint64(l) << uint64(r))
+		},
+	), nil
+}
+
+func integerRightShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return integerOperation(left, right,
+		nil,
+		func(l, r *big.Int) (*big.Int, bool) {
+			if r.Sign() < 0 {
+				return nil, false
+			}
+
+			// anything shifted this far is all sign bits, shifting further changes nothing.
+			if r.Cmp(big.NewInt(128)) > 0 {
+				r.SetInt64(128)
+			}
+			return l.Rsh(l, uint(r.Uint64())), true
+		},
+		func(l, r float64) float64 {
+			return float64(uint64(l) >> uint64(r))
+		},
+	), nil
+}
+
+func integerNegateStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	switch right := right.(type) {
+	case int64:
+		if right != math.MinInt64 {
+			return -right, nil
+		}
+	case float64:
+		return -right, nil
+	}
+
+	value := asBigInt(right)
+	return fromBigInt(value.Neg(value)), nil
+}
+
+func integerBitwiseNotStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	switch right := right.(type) {
+	case int64:
+		return ^right, nil
+	case float64:
+		return float64(^int64(right)), nil
+	}
+
+	value := asBigInt(right)
+	return fromBigInt(value.Not(value)), nil
+}
+
+func integerGteStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) >= right.(string)), nil
+	}
+	comparison, ok := compareNumbers(left, right)
+	return boolIface(ok && comparison >= 0), nil
+}
+func integerGtStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) > right.(string)), nil
+	}
+	comparison, ok := compareNumbers(left, right)
+	return boolIface(ok && comparison > 0), nil
+}
+func integerLteStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) <= right.(string)), nil
+	}
+	comparison, ok := compareNumbers(left, right)
+	return boolIface(ok && comparison <= 0), nil
+}
+func integerLtStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isString(left) && isString(right) {
+		return boolIface(left.(string) < right.(string)), nil
+	}
+	comparison, ok := compareNumbers(left, right)
+	return boolIface(ok && comparison < 0), nil
+}
+func integerEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return boolIface(numbersEqual(left, right)), nil
+}
+func integerNotEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return boolIface(!numbersEqual(left, right)), nil
+}
+
+func integerInStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	for _, value := range right.([]interface{}) {
+		if numbersEqual(left, castToInteger(value)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/*
+Performs an arithmetic operation on [left] and [right].
+If both are int64, [fast] is tried first. If both are integers, [slow] is used.
+If either is a float64, or if [fast] and [slow] both decline (by returning false), [float] is used instead.
+*/
+func integerOperation(
+	left interface{},
+	right interface{},
+	fast func(l, r int64) (int64, bool),
+	slow func(l, r *big.Int) (*big.Int, bool),
+	float func(l, r float64) float64) interface{} {
+
+	if fast != nil {
+		l, leftOk := left.(int64)
+		r, rightOk := right.(int64)
+
+		if leftOk && rightOk {
+			ret, ok := fast(l, r)
+			if ok {
+				return ret
+			}
+		}
+	}
+
+	if isInteger(left) && isInteger(right) {
+		ret, ok := slow(asBigInt(left), asBigInt(right))
+		if ok {
+			return fromBigInt(ret)
+		}
+	}
+
+	return float(asFloat64(left), asFloat64(right))
+}
+
+/*
+Returns -1, 0, or 1 as [left] is less than, equal to, or greater than [right].
+Integers and floats are compared exactly, without first converting the integer to a float.
+Returns false if the two cannot be ordered, because one of them is NaN.
+*/
+func compareNumbers(left interface{}, right interface{}) (int, bool) {
+
+	switch l := left.(type) {
+	case int64:
+		if r, ok := right.(int64); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			case l == r:
+				return 0, true
+			}
+			return 0, false
+		}
+	}
+
+	if isInteger(left) && isInteger(right) {
+		return asBigInt(left).Cmp(asBigInt(right)), true
+	}
+
+	l := asBigFloat(left)
+	r := asBigFloat(right)
+	if l == nil || r == nil {
+		return 0, false
+	}
+	return l.Cmp(r), true
+}
+
+/*
+Equality which considers numbers equal if they have the same value, regardless of whether they're integers or floats.
+Anything else is compared the same way the `==` operator usually does.
+*/
+func numbersEqual(left interface{}, right interface{}) bool {
+
+	if isNumber(left) && isNumber(right) {
+		comparison, ok := compareNumbers(left, right)
+		return ok && comparison == 0
+	}
+	return valuesEqual(left, right)
+}
+
+func asBigInt(value interface{}) *big.Int {
+
+	switch value := value.(type) {
+	case int64:
+		return big.NewInt(value)
+	case uint64:
+		return new(big.Int).SetUint64(value)
+	}
+	return nil
+}
+
+/*
+Converts an integer result back to the narrowest representation used for integers,
+or a float64 if it is too large (or too small) to be held by one.
+*/
+func fromBigInt(value *big.Int) interface{} {
+
+	if value.IsInt64() {
+		return value.Int64()
+	}
+	if value.IsUint64() {
+		return value.Uint64()
+	}
+
+	ret, _ := new(big.Float).SetInt(value).Float64()
+	return ret
+}
+
+func asBigFloat(value interface{}) *big.Float {
+
+	switch value := value.(type) {
+	case int64:
+		return new(big.Float).SetInt64(value)
+	case uint64:
+		return new(big.Float).SetUint64(value)
+	case float64:
+		if math.IsNaN(value) {
+			return nil
+		}
+		return big.NewFloat(value)
+	}
+	return nil
+}
+
+func asFloat64(value interface{}) float64 {
+
+	switch value := value.(type) {
+	case int64:
+		return float64(value)
+	case uint64:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return math.NaN()
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int64:
+		return true
+	case uint64:
+		return true
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case float64:
+		return true
+	case int64:
+		return true
+	case uint64:
+		return true
+	}
+	return false
+}
@@ -25,13 +25,17 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			VARIABLE,
+			ITEM,
 			PATTERN,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			STRING,
 			TIME,
 			CLAUSE,
+			LIST,
 		},
 	},
 
@@ -45,14 +49,18 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			VARIABLE,
+			ITEM,
 			PATTERN,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			STRING,
 			TIME,
 			CLAUSE,
 			CLAUSE_CLOSE,
+			LIST,
 		},
 	},
 
@@ -67,7 +75,9 @@ var validLexerStates = []lexerState{
 			MODIFIER,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			VARIABLE,
+			ITEM,
 			STRING,
 			PATTERN,
 			TIME,
@@ -76,6 +86,9 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
 		},
 	},
 
@@ -92,6 +105,8 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -107,6 +122,25 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX_CLOSE,
+		},
+	},
+	{
+
+		kind:       NIL,
+		isEOF:      true,
+		isNullable: true,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			CLAUSE_CLOSE,
+			TERNARY,
+			SEPARATOR,
+			LIST_CLOSE,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -122,6 +156,9 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -136,6 +173,8 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -150,6 +189,8 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -165,6 +206,28 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
+		},
+	},
+	{
+
+		kind:       ITEM,
+		isEOF:      true,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			CLAUSE_CLOSE,
+			TERNARY,
+			SEPARATOR,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
+			FIELD,
 		},
 	},
 	{
@@ -177,12 +240,17 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			STRING,
+			TIME,
 			BOOLEAN,
+			NIL,
 			CLAUSE,
 			CLAUSE_CLOSE,
+			LIST,
 		},
 	},
 	{
@@ -195,14 +263,18 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			STRING,
 			TIME,
 			CLAUSE,
 			CLAUSE_CLOSE,
 			PATTERN,
+			LIST,
 		},
 	},
 	{
@@ -215,13 +287,17 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			STRING,
 			TIME,
 			CLAUSE,
 			CLAUSE_CLOSE,
+			LIST,
 		},
 	},
 	{
@@ -234,7 +310,9 @@ var validLexerStates = []lexerState{
 			NUMERIC,
 			BOOLEAN,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			CLAUSE,
 			CLAUSE_CLOSE,
@@ -251,13 +329,17 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			STRING,
 			TIME,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			CLAUSE,
 			SEPARATOR,
+			LIST,
 		},
 	},
 	{
@@ -269,6 +351,15 @@ var validLexerStates = []lexerState{
 			CLAUSE,
 		},
 	},
+	{
+
+		kind:       QUANTIFIER,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+			CLAUSE,
+		},
+	},
 	{
 
 		kind:       ACCESSOR,
@@ -282,6 +373,9 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
 		},
 	},
 	{
@@ -294,12 +388,118 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NIL,
 			STRING,
 			TIME,
 			VARIABLE,
+			ITEM,
 			FUNCTION,
+			QUANTIFIER,
 			ACCESSOR,
 			CLAUSE,
+			LIST,
+		},
+	},
+
+	{
+
+		kind:       LIST,
+		isEOF:      false,
+		isNullable: true,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NIL,
+			VARIABLE,
+			ITEM,
+			PATTERN,
+			FUNCTION,
+			QUANTIFIER,
+			ACCESSOR,
+			STRING,
+			TIME,
+			CLAUSE,
+			LIST,
+			LIST_CLOSE,
+		},
+	},
+	{
+
+		kind:       LIST_CLOSE,
+		isEOF:      true,
+		isNullable: true,
+		validNextKinds: []TokenKind{
+
+			COMPARATOR,
+			MODIFIER,
+			LOGICALOP,
+			TERNARY,
+			SEPARATOR,
+			CLAUSE_CLOSE,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
+		},
+	},
+	{
+
+		kind:       INDEX,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NIL,
+			VARIABLE,
+			ITEM,
+			FUNCTION,
+			QUANTIFIER,
+			ACCESSOR,
+			STRING,
+			TIME,
+			CLAUSE,
+			LIST,
+		},
+	},
+	{
+
+		kind:       INDEX_CLOSE,
+		isEOF:      true,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			TERNARY,
+			SEPARATOR,
+			CLAUSE_CLOSE,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
+			FIELD,
+		},
+	},
+	{
+
+		kind:       FIELD,
+		isEOF:      true,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			TERNARY,
+			SEPARATOR,
+			CLAUSE_CLOSE,
+			LIST_CLOSE,
+			INDEX,
+			INDEX_CLOSE,
 		},
 	},
 }
@@ -316,7 +516,11 @@ func (l lexerState) canTransitionTo(kind TokenKind) bool {
 	return false
 }
 
-func checkExpressionSyntax(tokens []ExpressionToken) error {
+/*
+Checks that the given [tokens] form a valid sequence.
+If [positions] is given, the returned error points at the offending token.
+*/
+func checkExpressionSyntax(tokens []ExpressionToken, positions *tokenPositions) error {
 
 	var state lexerState
 	var lastToken ExpressionToken
@@ -324,19 +528,19 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 
 	state = validLexerStates[0]
 
-	for _, token := range tokens {
+	for index, token := range tokens {
 
 		if !state.canTransitionTo(token.Kind) {
 
 			// call out a specific error for tokens looking like they want to be functions.
 			if lastToken.Kind == VARIABLE && token.Kind == CLAUSE {
-				return errors.New("Undefined function " + lastToken.Value.(string))
+				return positions.errorAt(index-1, PARSE_UNDEFINED_FUNCTION, "Undefined function "+lastToken.Value.(string))
 			}
 
 			firstStateName := fmt.Sprintf("%s [%v]", state.kind.String(), lastToken.Value)
 			nextStateName := fmt.Sprintf("%s [%v]", token.Kind.String(), token.Value)
 
-			return errors.New("Cannot transition token types from " + firstStateName + " to " + nextStateName)
+			return positions.errorAt(index, PARSE_INVALID_TRANSITION, "Cannot transition token types from "+firstStateName+" to "+nextStateName)
 		}
 
 		state, err = getLexerStateForToken(token.Kind)
@@ -347,14 +551,14 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 		if !state.isNullable && token.Value == nil {
 
 			errorMsg := fmt.Sprintf("token kind '%v' cannot have a nil value", token.Kind.String())
-			return errors.New(errorMsg)
+			return positions.errorAt(index, PARSE_NIL_VALUE, errorMsg)
 		}
 
 		lastToken = token
 	}
 
 	if !state.isEOF {
-		return errors.New("unexpected end of expression")
+		return positions.errorAt(len(tokens), PARSE_UNEXPECTED_END, "unexpected end of expression")
 	}
 	return nil
 }
@@ -1,7 +1,9 @@
 package govaluate
 
 import (
+	"strings"
 	"sync"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -11,6 +13,9 @@ type lexerStream struct {
 	strPosition  int
 	position     int
 	length       int
+
+	// byte offset at which the token currently being read starts.
+	tokenStart int
 }
 
 var lexerStreamPool = sync.Pool{
@@ -30,6 +35,7 @@ func newLexerStream(source string) *lexerStream {
 	ret.sourceString = source
 	ret.position = 0
 	ret.strPosition = 0
+	ret.tokenStart = 0
 	ret.length = len(ret.source)
 	return ret
 }
@@ -63,7 +69,49 @@ func (stream lexerStream) canRead() bool {
 	return stream.position < stream.length
 }
 
+/*
+Returns the next character which isn't whitespace, without reading it, or 0 if there isn't one.
+*/
+func (stream lexerStream) nextNonSpace() rune {
+
+	for position := stream.position; position < stream.length; position++ {
+		if !unicode.IsSpace(stream.source[position]) {
+			return stream.source[position]
+		}
+	}
+	return 0
+}
+
+/*
+Returns whether the stream is at a `?.` which is followed by a name, such as the optional chaining in "user?.Address".
+*/
+func (stream lexerStream) atOptionalChain() bool {
+
+	position := stream.position
+	return position+2 < stream.length &&
+		stream.source[position] == '?' &&
+		stream.source[position+1] == '.' &&
+		unicode.IsLetter(stream.source[position+2])
+}
+
 func (stream *lexerStream) close() {
 	stream.source = stream.source[:0]
 	lexerStreamPool.Put(stream)
 }
+
+/*
+Creates a ParseError for the token currently being read, spanning from its start to the current position of the stream.
+*/
+func (stream *lexerStream) tokenError(code ParseErrorCode, message string) *ParseError {
+
+	end := stream.strPosition
+	if end > len(stream.sourceString) {
+		end = len(stream.sourceString)
+	}
+	if end < stream.tokenStart {
+		end = stream.tokenStart
+	}
+
+	token := strings.TrimRightFunc(stream.sourceString[stream.tokenStart:end], unicode.IsSpace)
+	return newParseError(code, stream.sourceString, stream.tokenStart, token, message)
+}
@@ -0,0 +1,201 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+Represents a test of evaluating an expression which uses list literals.
+*/
+type ListLiteralTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestListLiterals(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"arguments": func(arguments ...interface{}) (interface{}, error) {
+			return arguments, nil
+		},
+	}
+
+	listTests := []ListLiteralTest{
+
+		{
+			Name:     "List",
+			Input:    "{1, 'two', true}",
+			Expected: []interface{}{1.0, "two", true},
+		},
+		{
+			Name:     "Empty list",
+			Input:    "{}",
+			Expected: []interface{}{},
+		},
+		{
+			Name:     "Single element list",
+			Input:    "{1}",
+			Expected: []interface{}{1.0},
+		},
+		{
+			Name:     "Nested lists",
+			Input:    "{{1, 2}, {3}, {}}",
+			Expected: []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0}, []interface{}{}},
+		},
+		{
+			Name:       "List of expressions",
+			Input:      "{1 + 2, a ? 'b' : 'c', -1}",
+			Parameters: map[string]interface{}{"a": true},
+			Expected:   []interface{}{3.0, "b", -1.0},
+		},
+		{
+			Name:       "List of an array",
+			Input:      "{a}",
+			Parameters: map[string]interface{}{"a": []interface{}{1, 2}},
+			Expected:   []interface{}{[]interface{}{1, 2}},
+		},
+		{
+			Name:     "List of a parenthesized array",
+			Input:    "{(1, 2), 3}",
+			Expected: []interface{}{[]interface{}{1.0, 2.0}, 3.0},
+		},
+		{
+			Name:       "Membership",
+			Input:      "role in {'admin', 'ops'}",
+			Parameters: map[string]interface{}{"role": "ops"},
+			Expected:   true,
+		},
+		{
+			Name:     "Membership in an empty list",
+			Input:    "1 in {}",
+			Expected: false,
+		},
+		{
+			Name:     "Membership of a list",
+			Input:    "{1, 2} in {{1, 2}} && !({1} in {{1, 2}, 1})",
+			Expected: true,
+		},
+		{
+			Name:     "Equality",
+			Input:    "{1, {'a'}} == {1, {'a'}} && {1} != {1, 1}",
+			Expected: true,
+		},
+		{
+			Name:       "Ternary branch",
+			Input:      "a ? {1} : {2, 3}",
+			Parameters: map[string]interface{}{"a": false},
+			Expected:   []interface{}{2.0, 3.0},
+		},
+		{
+			Name:       "Coalesced list",
+			Input:      "a ?? {}",
+			Parameters: map[string]interface{}{"a": nil},
+			Expected:   []interface{}{},
+		},
+		{
+			Name:     "Function argument among others",
+			Input:    "arguments({1, 2}, 3)",
+			Expected: []interface{}{[]interface{}{1.0, 2.0}, 3.0},
+		},
+		{
+			Name:       "Array parameter among arguments",
+			Input:      "arguments(a, 3)",
+			Parameters: map[string]interface{}{"a": []interface{}{1, 2}},
+			Expected:   []interface{}{[]interface{}{1, 2}, 3.0},
+		},
+		{
+			Name:     "Only function argument",
+			Input:    "arguments({1, 2})",
+			Expected: []interface{}{[]interface{}{1.0, 2.0}},
+		},
+		{
+			Name:     "Empty function argument",
+			Input:    "arguments({})",
+			Expected: []interface{}{[]interface{}{}},
+		},
+		{
+			Name:       "Parenthesized function argument",
+			Input:      "arguments(({a}))",
+			Parameters: map[string]interface{}{"a": 1},
+			Expected:   []interface{}{[]interface{}{1.0}},
+		},
+		{
+			Name:       "Spread array parameter",
+			Input:      "arguments(a)",
+			Parameters: map[string]interface{}{"a": []interface{}{1, 2}},
+			Expected:   []interface{}{1, 2},
+		},
+	}
+
+	for _, listTest := range listTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(listTest.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", listTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(listTest.Parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", listTest.Name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, listTest.Expected) {
+			test.Errorf("Test '%s' failed: expected %v, got %v", listTest.Name, listTest.Expected, result)
+		}
+	}
+}
+
+func TestListLiteralArguments(test *testing.T) {
+
+	options := ExpressionOptions{
+		Functions: map[string]FunctionDefinition{
+			"count": {
+				Function: func(arguments ...interface{}) (interface{}, error) {
+					return float64(len(arguments)), nil
+				},
+				Pure: true,
+			},
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("count({a, b, 3}) + count(({})) * 10 + count(c) * 100", nil, options)
+
+	residual, err := expression.PartialEval(MapParameters{"a": 1, "b": 2})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	result, err := residual.Evaluate(map[string]interface{}{"c": []interface{}{1, 2}})
+	if err != nil || result != 211.0 {
+		test.Errorf("Expected the residual '%s' to give 211, got %v: %v", residual.String(), result, err)
+	}
+}
+
+func TestListLiteralTypeCheck(test *testing.T) {
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"role": StringType,
+			"n":    NumberType,
+		},
+	}
+
+	expression, _ := NewEvaluableExpression("role in {'admin', 'ops'} && {n} != {}")
+
+	err := expression.TypeCheck(schema)
+	if err != nil {
+		test.Errorf("Expected lists to type check, got: %v", err)
+	}
+
+	expression, _ = NewEvaluableExpression("{n, role - 1}")
+
+	err = expression.TypeCheck(schema)
+	if err == nil {
+		test.Errorf("Expected the elements of a list to be type checked")
+	}
+}
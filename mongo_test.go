@@ -0,0 +1,261 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of creating a MongoDB filter from an expression.
+*/
+type MongoQueryTest struct {
+	Name     string
+	Input    string
+	Expected map[string]interface{}
+}
+
+type mongoDocument = map[string]interface{}
+
+func TestMongoQueries(test *testing.T) {
+
+	created := time.Date(2014, 1, 2, 0, 0, 0, 0, time.Local)
+
+	testCases := []MongoQueryTest{
+
+		{
+			Name:     "Equality",
+			Input:    "name == 'ada'",
+			Expected: mongoDocument{"name": "ada"},
+		},
+		{
+			Name:     "Inequality",
+			Input:    "name != 'ada'",
+			Expected: mongoDocument{"name": mongoDocument{"$ne": "ada"}},
+		},
+		{
+			Name:     "Comparisons",
+			Input:    "age > 30 && age <= 65",
+			Expected: mongoDocument{"$and": []interface{}{mongoDocument{"age": mongoDocument{"$gt": 30.0}}, mongoDocument{"age": mongoDocument{"$lte": 65.0}}}},
+		},
+		{
+			Name:     "Literal on the left",
+			Input:    "30 < age",
+			Expected: mongoDocument{"age": mongoDocument{"$gt": 30.0}},
+		},
+		{
+			Name:  "Chained ors",
+			Input: "a == 1 || b == 2 || (c == 3)",
+			Expected: mongoDocument{"$or": []interface{}{
+				mongoDocument{"a": 1.0},
+				mongoDocument{"b": 2.0},
+				mongoDocument{"c": 3.0},
+			}},
+		},
+		{
+			Name:  "Nested operators",
+			Input: "a == 1 && (b == 2 || c == 3)",
+			Expected: mongoDocument{"$and": []interface{}{
+				mongoDocument{"a": 1.0},
+				mongoDocument{"$or": []interface{}{mongoDocument{"b": 2.0}, mongoDocument{"c": 3.0}}},
+			}},
+		},
+		{
+			Name:     "Negative number",
+			Input:    "a == -1",
+			Expected: mongoDocument{"a": -1.0},
+		},
+		{
+			Name:     "Negative bound",
+			Input:    "a > -5",
+			Expected: mongoDocument{"a": mongoDocument{"$gt": -5.0}},
+		},
+		{
+			Name:     "Negative number on the left",
+			Input:    "-5 < a",
+			Expected: mongoDocument{"a": mongoDocument{"$gt": -5.0}},
+		},
+		{
+			Name:     "Negative membership",
+			Input:    "a in (-1, 2)",
+			Expected: mongoDocument{"a": mongoDocument{"$in": []interface{}{-1.0, 2.0}}},
+		},
+		{
+			Name:     "Membership",
+			Input:    "role in ('admin', 'owner')",
+			Expected: mongoDocument{"role": mongoDocument{"$in": []interface{}{"admin", "owner"}}},
+		},
+		{
+			Name:     "List membership",
+			Input:    "level in {1, 2, 3}",
+			Expected: mongoDocument{"level": mongoDocument{"$in": []interface{}{1.0, 2.0, 3.0}}},
+		},
+		{
+			Name:     "Pattern",
+			Input:    "name =~ '^a'",
+			Expected: mongoDocument{"name": mongoDocument{"$regex": "^a"}},
+		},
+		{
+			Name:     "Negated pattern",
+			Input:    "name !~ '^a'",
+			Expected: mongoDocument{"name": mongoDocument{"$not": mongoDocument{"$regex": "^a"}}},
+		},
+		{
+			Name:     "Nil",
+			Input:    "deleted == nil",
+			Expected: mongoDocument{"deleted": nil},
+		},
+		{
+			Name:     "Not nil",
+			Input:    "deleted != nil",
+			Expected: mongoDocument{"deleted": mongoDocument{"$exists": true, "$ne": nil}},
+		},
+		{
+			Name:     "Boolean field",
+			Input:    "active",
+			Expected: mongoDocument{"active": true},
+		},
+		{
+			Name:     "Inverted boolean field",
+			Input:    "!active",
+			Expected: mongoDocument{"active": mongoDocument{"$ne": true}},
+		},
+		{
+			Name:     "Inverted comparison",
+			Input:    "!(age > 30)",
+			Expected: mongoDocument{"age": mongoDocument{"$not": mongoDocument{"$gt": 30.0}}},
+		},
+		{
+			Name:     "Inverted negated pattern",
+			Input:    "!(name !~ '^a')",
+			Expected: mongoDocument{"name": mongoDocument{"$regex": "^a"}},
+		},
+		{
+			Name:  "Inverted clauses",
+			Input: "!(a == 1 || b == 2)",
+			Expected: mongoDocument{"$nor": []interface{}{
+				mongoDocument{"$or": []interface{}{mongoDocument{"a": 1.0}, mongoDocument{"b": 2.0}}},
+			}},
+		},
+		{
+			Name:     "Doubly inverted clauses",
+			Input:    "!(!(a == 1 || b == 2))",
+			Expected: mongoDocument{"$or": []interface{}{mongoDocument{"a": 1.0}, mongoDocument{"b": 2.0}}},
+		},
+		{
+			Name:     "Accessor",
+			Input:    "address.city == 'London'",
+			Expected: mongoDocument{"address.city": "London"},
+		},
+		{
+			Name:     "Time",
+			Input:    "created >= '2014-01-02'",
+			Expected: mongoDocument{"created": mongoDocument{"$gte": created}},
+		},
+		{
+			Name:     "True",
+			Input:    "true",
+			Expected: mongoDocument{},
+		},
+		{
+			Name:     "False",
+			Input:    "false",
+			Expected: mongoDocument{"$nor": []interface{}{mongoDocument{}}},
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		query, err := expression.ToMongoQuery()
+		if err != nil {
+			test.Errorf("Test '%s' failed to create query: %s", testCase.Name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(query, testCase.Expected) {
+			test.Errorf("Test '%s' created %v, expected %v", testCase.Name, query, testCase.Expected)
+		}
+	}
+}
+
+func TestMongoQueryFailures(test *testing.T) {
+
+	failures := []string{
+		"a + 1 > 2",
+		"a == b",
+		"1 == 1",
+		"a > 1 ? b : c",
+		"len(name) > 1",
+		"user.Name() == 'ada'",
+		"'abc' =~ name",
+		"any(users, # > 1)",
+		"'ada'",
+		"[$where] == 'sleep(100)'",
+		"[$expr] == 1",
+		"[a.$ne] == 1",
+		"[a\x00b] == 1",
+		"-a == 1",
+		"-1 == -2",
+	}
+
+	options := ExpressionOptions{Functions: StandardFunctionDefinitions()}
+
+	for _, input := range failures {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Failed to parse '%s': %s", input, err)
+			continue
+		}
+
+		_, err = expression.ToMongoQuery()
+		if err == nil {
+			test.Errorf("Expected '%s' to be unsupported in MongoDB queries", input)
+		}
+	}
+}
+
+func TestMongoQueryErrorMessages(test *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "List",
+			Input:    "a == {1, 2}",
+			Expected: "fields can only be compared with literals in MongoDB queries, not a list",
+		},
+		{
+			Name:     "Operator",
+			Input:    "a + 1 > 2",
+			Expected: "only fields can be compared in MongoDB queries, not the result of '+'",
+		},
+		{
+			Name:     "Quantifier",
+			Input:    "any(users, # > 1)",
+			Expected: "a quantifier is unsupported in MongoDB queries",
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		_, err = expression.ToMongoQuery()
+		if err == nil || err.Error() != testCase.Expected {
+			test.Errorf("Test '%s' failed with '%v', expected '%s'", testCase.Name, err, testCase.Expected)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package govaluate
+
+import (
+	"errors"
+	"testing"
+)
+
+/*
+Represents a test of evaluating an expression which uses nil, in every numeric mode.
+*/
+type NilLiteralTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestNilLiterals(test *testing.T) {
+
+	var nilPointer *dummyParameter
+	var nilMap map[string]interface{}
+
+	nilTests := []NilLiteralTest{
+
+		{
+			Name:     "Nil",
+			Input:    "nil",
+			Expected: nil,
+		},
+		{
+			Name:     "Null",
+			Input:    "null == nil",
+			Expected: true,
+		},
+		{
+			Name:       "Escaped parameter named nil",
+			Input:      "[nil] == 'a' && [null] != nil",
+			Parameters: map[string]interface{}{"nil": "a", "null": "b"},
+			Expected:   true,
+		},
+		{
+			Name:       "Nil parameter",
+			Input:      "a == nil",
+			Parameters: map[string]interface{}{"a": nil},
+			Expected:   true,
+		},
+		{
+			Name:       "Non-nil parameter",
+			Input:      "a == nil",
+			Parameters: map[string]interface{}{"a": 0},
+			Expected:   false,
+		},
+		{
+			Name:       "Nil on the left",
+			Input:      "nil != a",
+			Parameters: map[string]interface{}{"a": "x"},
+			Expected:   true,
+		},
+		{
+			Name:       "Nil pointer",
+			Input:      "a == nil",
+			Parameters: map[string]interface{}{"a": nilPointer},
+			Expected:   true,
+		},
+		{
+			Name:       "Nil map",
+			Input:      "a != nil",
+			Parameters: map[string]interface{}{"a": nilMap},
+			Expected:   false,
+		},
+		{
+			Name:       "Guarded comparison",
+			Input:      "a != null && a > 3",
+			Parameters: map[string]interface{}{"a": nil},
+			Expected:   false,
+		},
+		{
+			Name:       "Coalesced",
+			Input:      "a ?? 'default'",
+			Parameters: map[string]interface{}{"a": nil},
+			Expected:   "default",
+		},
+		{
+			Name:       "Coalesced to nil",
+			Input:      "(a ?? nil) == nil",
+			Parameters: map[string]interface{}{"a": nil},
+			Expected:   true,
+		},
+		{
+			Name:     "Nil in a list",
+			Input:    "nil in {1, nil}",
+			Expected: true,
+		},
+	}
+
+	for _, numerics := range []NumericMode{FLOAT_NUMERICS, INTEGER_NUMERICS, DECIMAL_NUMERICS} {
+
+		for _, nilTest := range nilTests {
+
+			expression, err := NewEvaluableExpressionWithOptions(nilTest.Input, nil, ExpressionOptions{Numerics: numerics})
+			if err != nil {
+				test.Errorf("Test '%s' (%v) failed to parse: %v", nilTest.Name, numerics, err)
+				continue
+			}
+
+			result, err := expression.Evaluate(nilTest.Parameters)
+			if err != nil {
+				test.Errorf("Test '%s' (%v) failed to evaluate: %v", nilTest.Name, numerics, err)
+				continue
+			}
+
+			if result != nilTest.Expected {
+				test.Errorf("Test '%s' (%v) evaluated to '%v', expected '%v'", nilTest.Name, numerics, result, nilTest.Expected)
+			}
+		}
+	}
+}
+
+func TestNilOperandErrors(test *testing.T) {
+
+	failureTests := []EvaluationFailureTest{
+		{
+			Name:     "Nil compared",
+			Input:    "a > 3",
+			Expected: "Nil value cannot be used with the comparator '>'",
+		},
+		{
+			Name:     "Nil matched",
+			Input:    "a =~ 'x'",
+			Expected: "Nil value cannot be used with the comparator '=~'",
+		},
+		{
+			Name:     "Nil literal modified",
+			Input:    "nil + 1",
+			Expected: "Nil value cannot be used with the modifier '+'",
+		},
+		{
+			Name:     "Nil negated",
+			Input:    "-a",
+			Expected: "Nil value cannot be used with the prefix '-'",
+		},
+	}
+
+	for _, failureTest := range failureTests {
+
+		expression, err := NewEvaluableExpression(failureTest.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", failureTest.Name, err)
+			continue
+		}
+
+		_, err = expression.Evaluate(map[string]interface{}{"a": nil})
+
+		var mismatch *TypeMismatchError
+		if !errors.As(err, &mismatch) || err.Error() != failureTest.Expected {
+			test.Errorf("Test '%s' expected the error '%s', got '%v'", failureTest.Name, failureTest.Expected, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ func getParameterStage(name string) (*evaluationStage, error) {
 	operator := makeParameterStage(name)
 	return &evaluationStage{
 		operator: operator,
+		name:     name,
 	}, nil
 }
 
@@ -0,0 +1,113 @@
+package govaluate
+
+/*
+Switches every stage in the tree rooted at [root] to the operators used by the given numeric [mode].
+*/
+func useNumericMode(root *evaluationStage, mode NumericMode) {
+
+	switch mode {
+	case INTEGER_NUMERICS:
+		useNumericStages(root, integerStageSymbolMap, isNumber)
+	case DECIMAL_NUMERICS:
+		useNumericStages(root, decimalStageSymbolMap, isDecimalNumber)
+	}
+}
+
+/*
+Recurses through the entire tree, replacing the operators and type checks of every numeric stage
+with the equivalents given in [operators], for expressions that don't use FLOAT_NUMERICS.
+[number] determines which values those operators accept as numbers.
+*/
+func useNumericStages(root *evaluationStage, operators map[OperatorSymbol]evaluationOperator, number stageTypeCheck) {
+
+	if root == nil {
+		return
+	}
+
+	useNumericStages(root.leftStage, operators, number)
+	useNumericStages(root.rightStage, operators, number)
+
+	operator, found := operators[root.symbol]
+	if !found {
+		return
+	}
+
+	checks := findNumericTypeChecks(root.symbol, number)
+
+	root.operator = operator
+	root.leftTypeCheck = checks.left
+	root.rightTypeCheck = checks.right
+	root.typeCheck = checks.combined
+}
+
+/*
+Same as `findTypeChecks`, except that numeric operands are any value accepted by [number], rather than only float64.
+*/
+func findNumericTypeChecks(symbol OperatorSymbol, number stageTypeCheck) typeChecks {
+
+	switch symbol {
+	case GT:
+		fallthrough
+	case LT:
+		fallthrough
+	case GTE:
+		fallthrough
+	case LTE:
+		return typeChecks{
+			combined: func(left interface{}, right interface{}) bool {
+
+				if number(left) && number(right) {
+					return true
+				}
+				if isString(left) && isString(right) {
+					return true
+				}
+				return false
+			},
+		}
+	case PLUS:
+		return typeChecks{
+			combined: func(left interface{}, right interface{}) bool {
+
+				if number(left) && number(right) {
+					return true
+				}
+				if !isString(left) && !isString(right) {
+					return false
+				}
+				return true
+			},
+		}
+	case BITWISE_LSHIFT:
+		fallthrough
+	case BITWISE_RSHIFT:
+		fallthrough
+	case BITWISE_OR:
+		fallthrough
+	case BITWISE_AND:
+		fallthrough
+	case BITWISE_XOR:
+		fallthrough
+	case MINUS:
+		fallthrough
+	case MULTIPLY:
+		fallthrough
+	case DIVIDE:
+		fallthrough
+	case MODULUS:
+		fallthrough
+	case EXPONENT:
+		return typeChecks{
+			left:  number,
+			right: number,
+		}
+	case NEGATE:
+		fallthrough
+	case BITWISE_NOT:
+		return typeChecks{
+			right: number,
+		}
+	}
+
+	return findTypeChecks(symbol)
+}
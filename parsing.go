@@ -2,7 +2,6 @@ package govaluate
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -19,9 +18,13 @@ var (
 	samples       = make([]int, 0, 10)
 )
 
-func parseTokens(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+func parseTokens(expression string, functions map[string]ExpressionFunction, contextFunctions map[string]ContextExpressionFunction, options ExpressionOptions) ([]ExpressionToken, *tokenPositions, error) {
 	samplesMu.Lock()
 	ret := make([]ExpressionToken, 0, averageTokens)
+	positions := &tokenPositions{
+		source: expression,
+		spans:  make([]tokenSpan, 0, averageTokens),
+	}
 	samplesMu.Unlock()
 	var token ExpressionToken
 	var stream *lexerStream
@@ -34,10 +37,10 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 
 	for stream.canRead() {
 
-		token, err, found = readToken(stream, state, functions)
+		token, err, found = readToken(stream, state, functions, contextFunctions, options)
 
 		if err != nil {
-			return ret, err
+			return ret, positions, err
 		}
 
 		if !found {
@@ -46,11 +49,12 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
-			return ret, err
+			return ret, positions, err
 		}
 
 		// append this valid token
 		ret = append(ret, token)
+		positions.add(stream.tokenStart, stream.strPosition)
 	}
 	stream.close()
 	samplesMu.Lock()
@@ -67,17 +71,18 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 	averageTokens = total / len(samples)
 	samplesMu.Unlock()
 
-	err = checkBalance(ret)
+	err = checkBalance(ret, positions)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return ret, nil
+	return ret, positions, nil
 }
 
-func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction) (ExpressionToken, error, bool) {
+func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction, contextFunctions map[string]ContextExpressionFunction, options ExpressionOptions) (ExpressionToken, error, bool) {
 
 	var function ExpressionFunction
+	var contextFunction ContextExpressionFunction
 	var ret ExpressionToken
 	var tokenValue interface{}
 	var tokenTime time.Time
@@ -91,7 +96,9 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 	// numeric is 0-9, or . or 0x followed by digits
 	// string starts with '
 	// variable is alphanumeric, always starts with a letter
-	// bracket always means variable
+	// bracket means an index if it follows a value, otherwise a variable
+	// braces are a list
+	// '#' is the element a quantifier is looking at
 	// symbols are anything non-alphanumeric
 	// all others read into a buffer until they reach the end of the stream
 	for stream.canRead() {
@@ -102,6 +109,26 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			continue
 		}
 
+		stream.tokenStart = stream.strPosition - utf8.RuneLen(character)
+
+		// a field of something that was indexed, such as the ".Name" of "items[0].Name"
+		if character == '.' && state.canTransitionTo(FIELD) {
+
+			tokenString = readTokenUntilFalse(stream, isVariableName)
+			splits := strings.Split(tokenString[1:], ".")
+
+			for _, split := range splits {
+				if split == "" {
+					errorMsg := fmt.Sprintf("Hanging accessor on token '%s'", tokenString)
+					return ExpressionToken{}, stream.tokenError(PARSE_HANGING_ACCESSOR, errorMsg), false
+				}
+			}
+
+			kind = FIELD
+			tokenValue = splits
+			break
+		}
+
 		// numeric constant
 		if isNumeric(character) {
 
@@ -114,11 +141,18 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 					if err != nil {
 						errorMsg := fmt.Sprintf("Unable to parse hex value '%v' to uint64\n", tokenString)
-						return ExpressionToken{}, errors.New(errorMsg), false
+						return ExpressionToken{}, stream.tokenError(PARSE_INVALID_HEX, errorMsg), false
 					}
 
 					kind = NUMERIC
-					tokenValue = float64(tokenValueInt)
+					switch options.Numerics {
+					case INTEGER_NUMERICS:
+						tokenValue = castToInteger(tokenValueInt)
+					case DECIMAL_NUMERICS:
+						tokenValue = castToDecimal(tokenValueInt)
+					default:
+						tokenValue = float64(tokenValueInt)
+					}
 					break
 				} else {
 					stream.rewind(1)
@@ -126,11 +160,26 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			}
 
 			tokenString = readTokenUntilFalse(stream, isNumeric)
+
+			switch options.Numerics {
+			case INTEGER_NUMERICS:
+				tokenValue, found = parseIntegerLiteral(tokenString)
+			case DECIMAL_NUMERICS:
+				tokenValue, found = parseDecimalLiteral(tokenString)
+			default:
+				found = false
+			}
+
+			if found {
+				kind = NUMERIC
+				break
+			}
+
 			tokenValue, err = strconv.ParseFloat(tokenString, 64)
 
 			if err != nil {
 				errorMsg := fmt.Sprintf("Unable to parse numeric value '%v' to float64\n", tokenString)
-				return ExpressionToken{}, errors.New(errorMsg), false
+				return ExpressionToken{}, stream.tokenError(PARSE_INVALID_NUMERIC, errorMsg), false
 			}
 			kind = NUMERIC
 			break
@@ -144,6 +193,25 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		if character == '[' && state.canTransitionTo(INDEX) {
+			tokenValue = character
+			kind = INDEX
+			break
+		}
+
+		if character == ']' {
+			tokenValue = character
+			kind = INDEX_CLOSE
+			break
+		}
+
+		// the element a quantifier is currently looking at
+		if character == '#' {
+			tokenValue = character
+			kind = ITEM
+			break
+		}
+
 		// escaped variable
 		if character == '[' {
 
@@ -151,7 +219,7 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			kind = VARIABLE
 
 			if !completed {
-				return ExpressionToken{}, errors.New("unclosed parameter bracket"), false
+				return ExpressionToken{}, stream.tokenError(PARSE_UNCLOSED_BRACKET, "unclosed parameter bracket"), false
 			}
 
 			// above method normally rewinds us to the closing bracket, which we want to skip.
@@ -163,6 +231,14 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		if unicode.IsLetter(character) {
 
 			tokenString = readTokenUntilFalse(stream, isVariableName)
+
+			// optional chaining, such as "user?.Address", continues the name.
+			for stream.atOptionalChain() {
+				stream.readCharacter()
+				stream.readCharacter()
+				stream.readCharacter()
+				tokenString += "?." + readTokenUntilFalse(stream, isVariableName)
+			}
 			switch tokenString {
 			case "true":
 				kind = BOOLEAN
@@ -170,6 +246,11 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			case "false":
 				kind = BOOLEAN
 				tokenValue = false
+			case "nil":
+				fallthrough
+			case "null":
+				kind = NIL
+				tokenValue = nil
 			case "in":
 				fallthrough
 			case "IN":
@@ -183,10 +264,27 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			}
 
 			// function?
-			function, found = functions[tokenString]
-			if found {
+			definition, defined := options.Functions[tokenString]
+			if defined && definition.Function != nil {
+				kind = FUNCTION
+				tokenValue = definition.Function
+			} else if defined && definition.ContextFunction != nil {
+				kind = FUNCTION
+				tokenValue = definition.ContextFunction
+			} else if function, found = functions[tokenString]; found {
 				kind = FUNCTION
 				tokenValue = function
+			} else {
+				contextFunction, found = contextFunctions[tokenString]
+				if found {
+					kind = FUNCTION
+					tokenValue = contextFunction
+				}
+			}
+
+			// quantifier? only if it's called, so that parameters can still have the same names.
+			if kind == VARIABLE && quantifierNames[tokenString] && stream.nextNonSpace() == '(' {
+				kind = QUANTIFIER
 			}
 
 			// accessor?
@@ -196,11 +294,19 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				// check that it doesn't end with a hanging period
 				if tokenString[len(tokenString)-1] == '.' {
 					errorMsg := fmt.Sprintf("Hanging accessor on token '%s'", tokenString)
-					return ExpressionToken{}, errors.New(errorMsg), false
+					return ExpressionToken{}, stream.tokenError(PARSE_HANGING_ACCESSOR, errorMsg), false
 				}
 
 				kind = ACCESSOR
 				splits := strings.Split(tokenString, ".")
+
+				// a name that's accessed optionally (that follows a `?.`) is marked with a leading '?'.
+				for i := 1; i < len(splits); i++ {
+					if strings.HasSuffix(splits[i-1], "?") {
+						splits[i-1] = strings.TrimSuffix(splits[i-1], "?")
+						splits[i] = "?" + splits[i]
+					}
+				}
 				tokenValue = splits
 			}
 			break
@@ -210,14 +316,16 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			tokenValue, completed = readUntilFalse(stream, true, false, true, isNotQuote)
 
 			if !completed {
-				return ExpressionToken{}, errors.New("unclosed string literal"), false
+				return ExpressionToken{}, stream.tokenError(PARSE_UNCLOSED_STRING, "unclosed string literal"), false
 			}
 
 			// advance the stream one position, since reading until false assumes the terminator is a real token
 			stream.rewind(-1)
 
 			// check to see if this can be parsed as a time.
-			tokenTime, found = tryParseTime(tokenValue.(string))
+			if !options.DisableDateLiterals {
+				tokenTime, found = tryParseTime(tokenValue.(string), options)
+			}
 			if found {
 				kind = TIME
 				tokenValue = tokenTime
@@ -239,6 +347,18 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		if character == '{' {
+			tokenValue = character
+			kind = LIST
+			break
+		}
+
+		if character == '}' {
+			tokenValue = character
+			kind = LIST_CLOSE
+			break
+		}
+
 		// must be a known symbol
 		tokenString = readTokenUntilFalse(stream, isNotAlphanumeric)
 		tokenValue = tokenString
@@ -282,7 +402,7 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		}
 
 		errorMessage := fmt.Sprintf("Invalid token: '%s'", tokenString)
-		return ret, errors.New(errorMessage), false
+		return ret, stream.tokenError(PARSE_INVALID_TOKEN, errorMessage), false
 	}
 
 	ret.Kind = kind
@@ -291,6 +411,25 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 	return ret, nil, (kind != UNKNOWN)
 }
 
+/*
+Attempts to parse the given numeric literal as an integer.
+Returns false if it has a radix point, or is too large to fit even in a uint64.
+*/
+func parseIntegerLiteral(literal string) (interface{}, bool) {
+
+	signed, err := strconv.ParseInt(literal, 10, 64)
+	if err == nil {
+		return signed, true
+	}
+
+	unsigned, err := strconv.ParseUint(literal, 10, 64)
+	if err == nil {
+		return castToInteger(unsigned), true
+	}
+
+	return nil, false
+}
+
 func readTokenUntilFalse(stream *lexerStream, condition func(rune) bool) string {
 
 	var ret string
@@ -415,38 +554,92 @@ func optimizeTokens(tokens []ExpressionToken) ([]ExpressionToken, error) {
 	return tokens, nil
 }
 
+// the kind of token which opens each kind of token that closes a group, such as a parenthesis.
+var openingKinds = map[TokenKind]TokenKind{
+	CLAUSE_CLOSE: CLAUSE,
+	LIST_CLOSE:   LIST,
+	INDEX_CLOSE:  INDEX,
+}
+
 /*
-Checks the balance of tokens which have multiple parts, such as parenthesis.
+Checks the balance of tokens which have multiple parts, such as parenthesis, the braces of lists, and the brackets of indexes.
+If [positions] is given, the returned error points at the offending parenthesis, brace or bracket.
 */
-func checkBalance(tokens []ExpressionToken) error {
+func checkBalance(tokens []ExpressionToken, positions *tokenPositions) error {
 
 	var stream *tokenStream
 	var token ExpressionToken
-	var parens int
+	var opened []int
+	var unopened int
+
+	// how many of each kind of opening token are still open.
+	balances := make(map[TokenKind]int)
 
 	stream = newTokenStream(tokens)
+	unopened = -1
 
 	for stream.hasNext() {
 
 		token = stream.next()
-		if token.Kind == CLAUSE {
-			parens++
-			continue
-		}
-		if token.Kind == CLAUSE_CLOSE {
-			parens--
-			continue
+		index := stream.index - 1
+
+		switch token.Kind {
+
+		case CLAUSE, LIST, INDEX:
+			balances[token.Kind]++
+			opened = append(opened, index)
+
+		case CLAUSE_CLOSE, LIST_CLOSE, INDEX_CLOSE:
+
+			opening := openingKinds[token.Kind]
+			balances[opening]--
+
+			if len(opened) == 0 {
+				if unopened < 0 {
+					unopened = index
+				}
+				continue
+			}
+
+			// nothing can be closed by the closing token of another kind; whatever is innermost was left open.
+			innermost := opened[len(opened)-1]
+			if tokens[innermost].Kind != opening {
+				stream.close()
+				return unbalancedError(tokens, positions, innermost)
+			}
+			opened = opened[:len(opened)-1]
 		}
 	}
 
 	stream.close()
 
-	if parens != 0 {
-		return errors.New("unbalanced parenthesis")
+	for _, balance := range balances {
+
+		if balance == 0 {
+			continue
+		}
+
+		// point at the first closing token that was never opened, or else the innermost one left open.
+		index := unopened
+		if index < 0 {
+			index = opened[len(opened)-1]
+		}
+		return unbalancedError(tokens, positions, index)
 	}
 	return nil
 }
 
+func unbalancedError(tokens []ExpressionToken, positions *tokenPositions, index int) error {
+
+	switch tokens[index].Kind {
+	case LIST, LIST_CLOSE:
+		return positions.errorAt(index, PARSE_UNBALANCED_BRACE, "unbalanced braces")
+	case INDEX, INDEX_CLOSE:
+		return positions.errorAt(index, PARSE_UNBALANCED_BRACKET, "unbalanced brackets")
+	}
+	return positions.errorAt(index, PARSE_UNBALANCED_PARENTHESIS, "unbalanced parenthesis")
+}
+
 func isHexDigit(character rune) bool {
 
 	character = unicode.ToLower(character)
@@ -478,6 +671,8 @@ func isNotAlphanumeric(character rune) bool {
 		character != ')' &&
 		character != '[' &&
 		character != ']' && // starting to feel like there needs to be an `isOperation` func (#59)
+		character != '{' &&
+		character != '}' &&
 		isNotQuote(character)
 }
 
@@ -502,14 +697,26 @@ type timeFormat struct {
 
 /*
 Attempts to parse the [candidate] as a Time.
-Tries a series of standardized date formats, returns the Time if one applies,
+Tries any layouts given by the [options], then a series of standardized date formats, returns the Time if one applies,
 otherwise returns false through the second return.
 */
-func tryParseTime(candidate string) (time.Time, bool) {
+func tryParseTime(candidate string, options ExpressionOptions) (time.Time, bool) {
 
 	var ret time.Time
 	var found bool
 
+	location := options.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	for _, layout := range options.DateLayouts {
+		ret, found = tryParseExactTime(candidate, layout, location)
+		if found {
+			return ret, true
+		}
+	}
+
 	if !strings.Contains(candidate, ":") && !strings.Contains(candidate, "-") {
 		// The blow formats either have a : or a - in them. If the string contains neither it cannot be a time string
 		return time.Now(), false
@@ -537,7 +744,7 @@ func tryParseTime(candidate string) (time.Time, bool) {
 		if len(candidate) < format.minLength || len(candidate) > format.maxLength {
 			continue
 		}
-		ret, found = tryParseExactTime(candidate, format.format)
+		ret, found = tryParseExactTime(candidate, format.format, location)
 		if found {
 			return ret, true
 		}
@@ -546,12 +753,12 @@ func tryParseTime(candidate string) (time.Time, bool) {
 	return time.Now(), false
 }
 
-func tryParseExactTime(candidate string, format string) (time.Time, bool) {
+func tryParseExactTime(candidate string, format string, location *time.Location) (time.Time, bool) {
 
 	var ret time.Time
 	var err error
 
-	ret, err = time.ParseInLocation(format, candidate, time.Local)
+	ret, err = time.ParseInLocation(format, candidate, location)
 	if err != nil {
 		return time.Now(), false
 	}
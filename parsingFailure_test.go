@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"errors"
 	"regexp/syntax"
 	"strings"
 	"testing"
@@ -228,3 +229,262 @@ func runParsingFailureTests(parsingTests []ParsingFailureTest, test *testing.T)
 		}
 	}
 }
+
+/*
+Represents a test that a parsing failure points at the right place in the expression.
+*/
+type ParseErrorPositionTest struct {
+	Name   string
+	Input  string
+	Code   ParseErrorCode
+	Offset int
+	Line   int
+	Column int
+	Token  string
+}
+
+func TestParseErrorPositions(test *testing.T) {
+
+	parsingTests := []ParseErrorPositionTest{
+		{
+			Name:   "Invalid token",
+			Input:  "foo => 1",
+			Code:   PARSE_INVALID_TOKEN,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "=>",
+		},
+		{
+			Name:   "Invalid token on a later line",
+			Input:  "foo > 1 &&\n  bar === 2",
+			Code:   PARSE_INVALID_TOKEN,
+			Offset: 17,
+			Line:   2,
+			Column: 7,
+			Token:  "===",
+		},
+		{
+			Name:   "Multibyte characters before the error",
+			Input:  "'ünïcode' = 1",
+			Code:   PARSE_INVALID_TOKEN,
+			Offset: 12,
+			Line:   1,
+			Column: 11,
+			Token:  "=",
+		},
+		{
+			Name:   "Unclosed string",
+			Input:  "foo == 'bar",
+			Code:   PARSE_UNCLOSED_STRING,
+			Offset: 7,
+			Line:   1,
+			Column: 8,
+			Token:  "'bar",
+		},
+		{
+			Name:   "Unclosed bracket",
+			Input:  "1 + [foo",
+			Code:   PARSE_UNCLOSED_BRACKET,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "[foo",
+		},
+		{
+			Name:   "Invalid numeric",
+			Input:  "1 + 127.0.0.1",
+			Code:   PARSE_INVALID_NUMERIC,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "127.0.0.1",
+		},
+		{
+			Name:   "Hanging accessor",
+			Input:  "1 + foo.Bar.",
+			Code:   PARSE_HANGING_ACCESSOR,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "foo.Bar.",
+		},
+		{
+			Name:   "Unopened parenthesis",
+			Input:  "(1 + 2)) + 3",
+			Code:   PARSE_UNBALANCED_PARENTHESIS,
+			Offset: 7,
+			Line:   1,
+			Column: 8,
+			Token:  ")",
+		},
+		{
+			Name:   "Unclosed parenthesis",
+			Input:  "(1 + (2 * 3)",
+			Code:   PARSE_UNBALANCED_PARENTHESIS,
+			Offset: 0,
+			Line:   1,
+			Column: 1,
+			Token:  "(",
+		},
+		{
+			Name:   "Unclosed list",
+			Input:  "a in {1, (2)",
+			Code:   PARSE_UNBALANCED_BRACE,
+			Offset: 5,
+			Line:   1,
+			Column: 6,
+			Token:  "{",
+		},
+		{
+			Name:   "List closed by a parenthesis",
+			Input:  "({1, 2)}",
+			Code:   PARSE_UNBALANCED_BRACE,
+			Offset: 1,
+			Line:   1,
+			Column: 2,
+			Token:  "{",
+		},
+		{
+			Name:   "Parenthesis closed by a brace",
+			Input:  "{(1, 2})",
+			Code:   PARSE_UNBALANCED_PARENTHESIS,
+			Offset: 1,
+			Line:   1,
+			Column: 2,
+			Token:  "(",
+		},
+		{
+			Name:   "Unclosed index",
+			Input:  "a[b[1] + 2",
+			Code:   PARSE_UNBALANCED_BRACKET,
+			Offset: 1,
+			Line:   1,
+			Column: 2,
+			Token:  "[",
+		},
+		{
+			Name:   "Index closed by a parenthesis",
+			Input:  "(a[1)]",
+			Code:   PARSE_UNBALANCED_BRACKET,
+			Offset: 2,
+			Line:   1,
+			Column: 3,
+			Token:  "[",
+		},
+		{
+			Name:   "Empty index",
+			Input:  "a[]",
+			Code:   PARSE_INVALID_TRANSITION,
+			Offset: 2,
+			Line:   1,
+			Column: 3,
+			Token:  "]",
+		},
+		{
+			Name:   "Hanging field",
+			Input:  "a[0].b.",
+			Code:   PARSE_HANGING_ACCESSOR,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  ".b.",
+		},
+		{
+			Name:   "Item outside of a quantifier",
+			Input:  "a > 1 && # == 2",
+			Code:   PARSE_UNBOUND_ITEM,
+			Offset: 9,
+			Line:   1,
+			Column: 10,
+			Token:  "#",
+		},
+		{
+			Name:   "Quantifier with one argument",
+			Input:  "1 + map(items)",
+			Code:   PARSE_ARGUMENT_COUNT,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "map",
+		},
+		{
+			Name:   "Quantifier with three arguments",
+			Input:  "any(items, # > 1, true)",
+			Code:   PARSE_ARGUMENT_COUNT,
+			Offset: 0,
+			Line:   1,
+			Column: 1,
+			Token:  "any",
+		},
+		{
+			Name:   "Undefined function",
+			Input:  "1 + foobar()",
+			Code:   PARSE_UNDEFINED_FUNCTION,
+			Offset: 4,
+			Line:   1,
+			Column: 5,
+			Token:  "foobar",
+		},
+		{
+			Name:   "Invalid transition",
+			Input:  "1 + 2 3",
+			Code:   PARSE_INVALID_TRANSITION,
+			Offset: 6,
+			Line:   1,
+			Column: 7,
+			Token:  "3",
+		},
+		{
+			Name:   "Unexpected end",
+			Input:  "10 > 5 &&  ",
+			Code:   PARSE_UNEXPECTED_END,
+			Offset: 9,
+			Line:   1,
+			Column: 10,
+		},
+	}
+
+	for _, testCase := range parsingTests {
+
+		_, err := NewEvaluableExpression(testCase.Input)
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			test.Errorf("Test '%s' failed: expected a *ParseError, got '%v'", testCase.Name, err)
+			continue
+		}
+
+		if parseError.Code != testCase.Code {
+			test.Errorf("Test '%s' failed: expected code %v, got %v", testCase.Name, testCase.Code, parseError.Code)
+		}
+		if parseError.Offset != testCase.Offset || parseError.Line != testCase.Line || parseError.Column != testCase.Column {
+			test.Errorf("Test '%s' failed: expected offset %d (%d:%d), got %d (%d:%d)", testCase.Name,
+				testCase.Offset, testCase.Line, testCase.Column,
+				parseError.Offset, parseError.Line, parseError.Column)
+		}
+		if parseError.Token != testCase.Token {
+			test.Errorf("Test '%s' failed: expected token '%s', got '%s'", testCase.Name, testCase.Token, parseError.Token)
+		}
+	}
+}
+
+func TestParseErrorWithoutSource(test *testing.T) {
+
+	_, err := NewEvaluableExpressionFromTokens([]ExpressionToken{
+		{Kind: NUMERIC, Value: 1.0},
+		{Kind: MODIFIER, Value: "+"},
+	})
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		test.Fatalf("Expected a *ParseError, got '%v'", err)
+	}
+
+	if parseError.Code != PARSE_UNEXPECTED_END {
+		test.Errorf("Expected code %v, got %v", PARSE_UNEXPECTED_END, parseError.Code)
+	}
+	if parseError.Offset != -1 || parseError.Line != 0 || parseError.Column != 0 {
+		test.Errorf("Expected no position, got offset %d (%d:%d)", parseError.Offset, parseError.Line, parseError.Column)
+	}
+}
@@ -16,6 +16,7 @@ type TokenParsingTest struct {
 	Name      string
 	Input     string
 	Functions map[string]ExpressionFunction
+	Options   *ExpressionOptions
 	Expected  []ExpressionToken
 }
 
@@ -132,6 +133,24 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "Time subtracted from a variable",
+			Input: "t - '2014-01-02'",
+			Expected: []ExpressionToken{
+				{
+					Kind:  VARIABLE,
+					Value: "t",
+				},
+				{
+					Kind:  MODIFIER,
+					Value: "-",
+				},
+				{
+					Kind:  TIME,
+					Value: time.Date(2014, time.January, 2, 0, 0, 0, 0, time.Local),
+				},
+			},
+		},
 		{
 			Name:  "Single boolean",
 			Input: "true",
@@ -182,6 +201,24 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "Single nil",
+			Input: "nil",
+			Expected: []ExpressionToken{
+				{
+					Kind: NIL,
+				},
+			},
+		},
+		{
+			Name:  "Single null",
+			Input: "null",
+			Expected: []ExpressionToken{
+				{
+					Kind: NIL,
+				},
+			},
+		},
 		{
 			Name:  "Single internationalized string",
 			Input: "'ÆŦǽഈᚥஇคٸ'",
@@ -431,6 +468,34 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "Optional accessor",
+			Input: "foo?.Var.Other?.Last",
+			Expected: []ExpressionToken{
+				{
+					Kind:  ACCESSOR,
+					Value: []string{"foo", "?Var", "Other", "?Last"},
+				},
+			},
+		},
+		{
+			Name:  "Ternary before a variable",
+			Input: "foo ?bar",
+			Expected: []ExpressionToken{
+				{
+					Kind:  VARIABLE,
+					Value: "foo",
+				},
+				{
+					Kind:  TERNARY,
+					Value: "?",
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "bar",
+				},
+			},
+		},
 	}
 
 	tokenParsingTests = combineWhitespaceExpressions(tokenParsingTests)
@@ -1518,6 +1583,95 @@ func TestOriginalString(test *testing.T) {
 	}
 }
 
+func TestDateLiteralOptions(test *testing.T) {
+
+	tokenParsingTests := []TokenParsingTest{
+		{
+			Name:    "Time in a location",
+			Input:   "'2014-01-02 14:12'",
+			Options: &ExpressionOptions{Location: time.UTC},
+			Expected: []ExpressionToken{
+				{
+					Kind:  TIME,
+					Value: time.Date(2014, time.January, 2, 14, 12, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			Name:    "Time with an additional layout",
+			Input:   "'02/01/2014'",
+			Options: &ExpressionOptions{Location: time.UTC, DateLayouts: []string{"02/01/2006"}},
+			Expected: []ExpressionToken{
+				{
+					Kind:  TIME,
+					Value: time.Date(2014, time.January, 2, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			Name:    "Time with an additional layout without separators",
+			Input:   "'20140102' < '2014-01-03'",
+			Options: &ExpressionOptions{Location: time.UTC, DateLayouts: []string{"20060102"}},
+			Expected: []ExpressionToken{
+				{
+					Kind:  TIME,
+					Value: time.Date(2014, time.January, 2, 0, 0, 0, 0, time.UTC),
+				},
+				{
+					Kind: COMPARATOR,
+				},
+				{
+					Kind:  TIME,
+					Value: time.Date(2014, time.January, 3, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			Name:    "String not matching additional layouts",
+			Input:   "'2014/01/02'",
+			Options: &ExpressionOptions{DateLayouts: []string{"02/01/2006"}},
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "2014/01/02",
+				},
+			},
+		},
+		{
+			Name:    "Dates disabled",
+			Input:   "'2014-01-02' != '10:30'",
+			Options: &ExpressionOptions{DisableDateLiterals: true, DateLayouts: []string{"2006-01-02"}},
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "2014-01-02",
+				},
+				{
+					Kind: COMPARATOR,
+				},
+				{
+					Kind:  STRING,
+					Value: "10:30",
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(tokenParsingTests, test)
+
+	options := ExpressionOptions{Location: time.UTC}
+
+	expression, err := NewEvaluableExpressionWithOptions("ts >= '2024-03-01 00:00'", nil, options)
+	if err != nil {
+		test.Fatalf("Unable to parse: %v", err)
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"ts": time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil || result != true {
+		test.Errorf("Expected a date literal parsed in UTC to equal midnight UTC, got %v (%v)", result, err)
+	}
+}
+
 /*
 Tests to make sure that the Vars() reprsentation of an expression identifies all variables contained within the expression.
 */
@@ -1624,7 +1778,9 @@ func runTokenParsingTest(tokenParsingTests []TokenParsingTest, test *testing.T)
 	// Run the test cases.
 	for _, parsingTest = range tokenParsingTests {
 
-		if parsingTest.Functions != nil {
+		if parsingTest.Options != nil {
+			expression, err = NewEvaluableExpressionWithOptions(parsingTest.Input, parsingTest.Functions, *parsingTest.Options)
+		} else if parsingTest.Functions != nil {
 			expression, err = NewEvaluableExpressionWithFunctions(parsingTest.Input, parsingTest.Functions)
 		} else {
 			expression, err = NewEvaluableExpression(parsingTest.Input)
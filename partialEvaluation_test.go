@@ -0,0 +1,458 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of partially evaluating an expression with some of its parameters.
+[Expected] is the residual expression's source text.
+*/
+type PartialEvaluationTest struct {
+	Name     string
+	Input    string
+	Known    map[string]interface{}
+	Expected string
+}
+
+func TestPartialEval(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"lower": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	partialTests := []PartialEvaluationTest{
+
+		{
+			Name:     "Nothing known",
+			Input:    "a > 1 && b",
+			Known:    map[string]interface{}{},
+			Expected: "a > 1 && b",
+		},
+		{
+			Name:     "Everything known",
+			Input:    "a + b * 2",
+			Known:    map[string]interface{}{"a": 1, "b": 2},
+			Expected: "5",
+		},
+		{
+			Name:     "Substitution",
+			Input:    "a + b",
+			Known:    map[string]interface{}{"a": 1},
+			Expected: "1 + b",
+		},
+		{
+			Name:     "Constant folding",
+			Input:    "b > a * (2 + 3)",
+			Known:    map[string]interface{}{"a": 2},
+			Expected: "b > 10",
+		},
+		{
+			Name:     "AND with a true side",
+			Input:    "a > 3 && b == c",
+			Known:    map[string]interface{}{"a": 5},
+			Expected: "b == c",
+		},
+		{
+			Name:     "AND with a false side",
+			Input:    "a > 10 && b == c",
+			Known:    map[string]interface{}{"a": 5},
+			Expected: "false",
+		},
+		{
+			Name:     "AND with a false right side",
+			Input:    "b == c && a > 10",
+			Known:    map[string]interface{}{"a": 5},
+			Expected: "false",
+		},
+		{
+			Name:     "OR with a true side",
+			Input:    "b == c || admin",
+			Known:    map[string]interface{}{"admin": true},
+			Expected: "true",
+		},
+		{
+			Name:     "OR with a false side",
+			Input:    "admin || owner == user",
+			Known:    map[string]interface{}{"admin": false, "user": "bob"},
+			Expected: "owner == 'bob'",
+		},
+		{
+			Name:     "Short circuit skips unknown errors",
+			Input:    "a && b > 'x'",
+			Known:    map[string]interface{}{"a": false, "b": 1},
+			Expected: "false",
+		},
+		{
+			Name:     "Known index",
+			Input:    "a[i] == b",
+			Known:    map[string]interface{}{"a": []interface{}{"x", "y"}, "i": 1},
+			Expected: "'y' == b",
+		},
+		{
+			Name:     "Known array with an unknown index",
+			Input:    "a[i] == b",
+			Known:    map[string]interface{}{"a": []interface{}{"x", "y"}},
+			Expected: "{'x', 'y'}[i] == b",
+		},
+		{
+			Name:     "Known quantifier",
+			Input:    "any(a, # > b) && c",
+			Known:    map[string]interface{}{"a": []interface{}{1, 5}, "b": 3},
+			Expected: "true && c",
+		},
+		{
+			Name:     "Known operand of a comparison",
+			Input:    "any(a, # > b) && c > 1",
+			Known:    map[string]interface{}{"a": []interface{}{1, 5}, "b": 3},
+			Expected: "c > 1",
+		},
+		{
+			Name:     "Known array with an unknown condition",
+			Input:    "filter(a, # > b && c)",
+			Known:    map[string]interface{}{"a": []interface{}{1, 5}, "c": true},
+			Expected: "filter({1, 5}, # > b)",
+		},
+		{
+			Name:     "Unknown array",
+			Input:    "map(a, # * b)",
+			Known:    map[string]interface{}{"b": 2},
+			Expected: "map(a, # * 2)",
+		},
+		{
+			Name:     "Coalesce with a known value",
+			Input:    "a ?? b",
+			Known:    map[string]interface{}{"a": "x"},
+			Expected: "'x'",
+		},
+		{
+			Name:     "Coalesce with a nil value",
+			Input:    "a ?? b",
+			Known:    map[string]interface{}{"a": nil},
+			Expected: "b",
+		},
+		{
+			Name:     "Compared with nil",
+			Input:    "a != nil && a > b",
+			Known:    map[string]interface{}{"a": nil},
+			Expected: "false",
+		},
+		{
+			Name:     "Evaluated to nil",
+			Input:    "a ?? b",
+			Known:    map[string]interface{}{"a": nil, "b": nil},
+			Expected: "nil",
+		},
+		{
+			Name:     "Ternary with a true condition",
+			Input:    "a ? b : c",
+			Known:    map[string]interface{}{"a": true},
+			Expected: "b ?? c",
+		},
+		{
+			Name:     "Ternary with a false condition",
+			Input:    "a ? b : c",
+			Known:    map[string]interface{}{"a": false},
+			Expected: "c",
+		},
+		{
+			Name:     "Ternary with unknown condition",
+			Input:    "a ? b + 1 : c",
+			Known:    map[string]interface{}{"b": 1},
+			Expected: "a ? 2 : c",
+		},
+		{
+			Name:     "Membership",
+			Input:    "resource in allowed",
+			Known:    map[string]interface{}{"allowed": []interface{}{"a", "b"}},
+			Expected: "resource in ('a', 'b')",
+		},
+		{
+			Name:     "Known list",
+			Input:    "{a, 1} == b",
+			Known:    map[string]interface{}{"a": []interface{}{"x"}},
+			Expected: "{{'x'}, 1} == b",
+		},
+		{
+			Name:     "Partly known list",
+			Input:    "{a, b, {c}}",
+			Known:    map[string]interface{}{"a": 1, "c": 2},
+			Expected: "{1, b, {2}}",
+		},
+		{
+			Name:     "Membership in a list",
+			Input:    "a in {b, 'c'}",
+			Known:    map[string]interface{}{"b": "c"},
+			Expected: "a in ('c', 'c')",
+		},
+		{
+			Name:     "Membership in an empty array",
+			Input:    "resource in allowed",
+			Known:    map[string]interface{}{"allowed": []interface{}{}},
+			Expected: "false",
+		},
+		{
+			Name:     "Known accessor",
+			Input:    "foo.Int + bar",
+			Known:    map[string]interface{}{"foo": dummyParameterInstance},
+			Expected: "101 + bar",
+		},
+		{
+			Name:     "Unknown accessor",
+			Input:    "foo.Int + bar",
+			Known:    map[string]interface{}{"bar": 1},
+			Expected: "foo.Int + 1",
+		},
+		{
+			Name:     "Functions are not run",
+			Input:    "lower(a) == b",
+			Known:    map[string]interface{}{"a": "X", "b": "x"},
+			Expected: "lower('X') == 'x'",
+		},
+		{
+			Name:     "Strings that look like dates",
+			Input:    "a == b",
+			Known:    map[string]interface{}{"a": "2014-01-02"},
+			Expected: "'2014-01-02' == b",
+		},
+		{
+			Name:     "Date literals",
+			Input:    "a > '2014-01-02 03:04:05+00:00' && b",
+			Known:    map[string]interface{}{"b": true},
+			Expected: "a > '2014-01-02 03:04:05+00:00'",
+		},
+	}
+
+	for _, partialTest := range partialTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(partialTest.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", partialTest.Name, err)
+			continue
+		}
+
+		residual, err := expression.PartialEval(MapParameters(partialTest.Known))
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", partialTest.Name, err)
+			continue
+		}
+
+		if residual.String() != partialTest.Expected {
+			test.Errorf("Test '%s' failed", partialTest.Name)
+			test.Logf("Expected: %s", partialTest.Expected)
+			test.Logf("Actual: %s", residual.String())
+		}
+	}
+}
+
+func TestPartialEvalMatchesEvaluation(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"a": 5,
+		"b": "x",
+		"c": "y",
+		"d": nil,
+		"e": true,
+	}
+
+	inputs := []string{
+		"a > 3 && b == c",
+		"e ? a * 2 : a / 2",
+		"(d ?? b) + c",
+		"!e || a - 1 >= 4",
+		"a in (1, 5) && b =~ '^x'",
+		"d == nil && a != null",
+		"any({1, a}, # > 4) && all({b, c}, # != d)",
+		"map(filter({a, 1, 2}, # > 1), # * a)[0]",
+	}
+
+	for _, input := range inputs {
+
+		expression, _ := NewEvaluableExpression(input)
+		expected, _ := expression.Evaluate(parameters)
+
+		// evaluate each residual with only one known parameter, then finish with the rest.
+		for name, value := range parameters {
+
+			residual, err := expression.PartialEval(MapParameters{name: value})
+			if err != nil {
+				test.Errorf("Unable to partially evaluate '%s' with '%s': %v", input, name, err)
+				continue
+			}
+
+			actual, err := residual.Evaluate(parameters)
+			if err != nil || actual != expected {
+				test.Errorf("'%s' evaluated to %v, but its residual '%s' (with '%s' known) evaluated to %v (%v)", input, expected, residual, name, actual, err)
+			}
+		}
+	}
+}
+
+func TestPartialEvalResidualTokens(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("owner == user && state == 'open'")
+
+	residual, err := expression.PartialEval(MapParameters{"user": "bob"})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	vars := residual.Vars()
+	if len(vars) != 2 || vars[0] != "owner" || vars[1] != "state" {
+		test.Errorf("Expected the residual to refer to 'owner' and 'state', got %v", vars)
+	}
+
+	query, err := residual.ToSQLQuery()
+	if err != nil {
+		test.Fatalf("Unable to create query from residual: %v", err)
+	}
+
+	expected := "[owner] = 'bob' AND [state] = 'open'"
+	if query != expected {
+		test.Errorf("Expected query '%s', got '%s'", expected, query)
+	}
+}
+
+func TestPartialEvalErrors(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a > 1 || b")
+
+	_, err := expression.PartialEval(MapParameters{"a": "x"})
+
+	var mismatch *TypeMismatchError
+	if !errors.As(err, &mismatch) {
+		test.Errorf("Expected a TypeMismatchError, got '%v'", err)
+	}
+}
+
+/*
+Represents a test of evaluating the residual of a partially evaluated expression, which may not be writable as source text.
+*/
+type PartialResidualTest struct {
+	Name       string
+	Input      string
+	Known      map[string]interface{}
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestPartialEvalResiduals(test *testing.T) {
+
+	created := time.Date(2024, time.March, 15, 13, 30, 0, 500000000, time.UTC)
+
+	residualTests := []PartialResidualTest{
+
+		{
+			Name:       "Known time with fractional seconds",
+			Input:      "updated > created",
+			Known:      map[string]interface{}{"created": created},
+			Parameters: map[string]interface{}{"updated": created.Add(100 * time.Millisecond)},
+			Expected:   true,
+		},
+		{
+			Name:       "Known duration",
+			Input:      "elapsed > limit",
+			Known:      map[string]interface{}{"limit": 90 * time.Second},
+			Parameters: map[string]interface{}{"elapsed": 2 * time.Minute},
+			Expected:   true,
+		},
+		{
+			Name:       "Known slice",
+			Input:      "ids[i] == 5",
+			Known:      map[string]interface{}{"ids": []int{4, 5}},
+			Parameters: map[string]interface{}{"i": 1},
+			Expected:   true,
+		},
+		{
+			Name:       "Known map with an unknown key",
+			Input:      "m[k].String == m['a'].String",
+			Known:      map[string]interface{}{"m": map[string]interface{}{"a": dummyParameterInstance}},
+			Parameters: map[string]interface{}{"k": "a"},
+			Expected:   true,
+		},
+		{
+			Name:       "Known array in a quantifier with an unknown condition",
+			Input:      "any(m, #.String == s)",
+			Known:      map[string]interface{}{"m": []dummyParameter{dummyParameterInstance}},
+			Parameters: map[string]interface{}{"s": "string!"},
+			Expected:   true,
+		},
+	}
+
+	for _, residualTest := range residualTests {
+
+		expression, err := NewEvaluableExpression(residualTest.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", residualTest.Name, err)
+			continue
+		}
+
+		residual, err := expression.PartialEval(MapParameters(residualTest.Known))
+		if err != nil {
+			test.Errorf("Test '%s' failed to partially evaluate: %v", residualTest.Name, err)
+			continue
+		}
+
+		actual, err := residual.Evaluate(residualTest.Parameters)
+		if err != nil || actual != residualTest.Expected {
+			test.Errorf("Test '%s' evaluated its residual '%s' to %v (%v), expected %v", residualTest.Name, residual, actual, err, residualTest.Expected)
+		}
+	}
+}
+
+func TestPartialEvalKeepsLogicalTypeChecks(test *testing.T) {
+
+	inputs := []string{
+		"x && y",
+		"y && x",
+		"x || z",
+		"z || x",
+	}
+
+	for _, input := range inputs {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		residual, err := expression.PartialEval(MapParameters{"y": true, "z": false})
+		if err != nil {
+			test.Errorf("Unable to partially evaluate '%s': %v", input, err)
+			continue
+		}
+
+		var mismatch *TypeMismatchError
+
+		_, err = residual.Evaluate(map[string]interface{}{"x": 5})
+		if !errors.As(err, &mismatch) {
+			test.Errorf("Expected the residual '%s' of '%s' to fail with a type mismatch, got: %v", residual, input, err)
+		}
+	}
+}
+
+func TestPartialEvalUnwritableResidualQuery(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("elapsed > limit")
+
+	residual, err := expression.PartialEval(MapParameters{"limit": 90 * time.Second})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	if residual.String() != "" || len(residual.Tokens()) != 0 {
+		test.Errorf("Expected a residual without text or tokens, got '%s'", residual)
+	}
+
+	query, err := residual.ToMongoQuery()
+	if err != nil {
+		test.Fatalf("Unable to create query from residual: %v", err)
+	}
+
+	expected := map[string]interface{}{"elapsed": map[string]interface{}{"$gt": 90 * time.Second}}
+	if !reflect.DeepEqual(query, expected) {
+		test.Errorf("Expected query %v, got %v", expected, query)
+	}
+}
@@ -0,0 +1,159 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+/*
+The quantifiers, which run an expression for each element of an array, with `#` as that element.
+`any`, `all` and `none` test a condition, `filter` keeps the elements which pass one, and `map` collects the results.
+*/
+var quantifierNames = map[string]bool{
+	"any":    true,
+	"all":    true,
+	"none":   true,
+	"filter": true,
+	"map":    true,
+}
+
+/*
+Runs the quantifier called [name] over the elements of [collection].
+[evaluate] is called for each element in turn, with parameters in which `#` is that element.
+`any`, `all` and `none` stop as soon as their result is known.
+*/
+func quantify(name string, collection interface{}, parameters Parameters, evaluate func(Parameters) (interface{}, error)) (interface{}, error) {
+
+	elements, err := quantifiedElements(name, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []interface{}
+	if name == "filter" || name == "map" {
+		ret = make([]interface{}, 0, len(elements))
+	}
+
+	for _, element := range elements {
+
+		element = sanitizeFor(parameters, element)
+
+		value, err := evaluate(withItem(parameters, element))
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "map" {
+			ret = append(ret, value)
+			continue
+		}
+
+		matched, ok := value.(bool)
+		if !ok {
+			return nil, newQuantifierTypeError(collection, value, fmt.Sprintf("Value '%v' cannot be used as the condition of '%s', it is not a bool", value, name))
+		}
+
+		switch name {
+		case "any":
+			if matched {
+				return true, nil
+			}
+		case "all":
+			if !matched {
+				return false, nil
+			}
+		case "none":
+			if matched {
+				return false, nil
+			}
+		case "filter":
+			if matched {
+				ret = append(ret, element)
+			}
+		}
+	}
+
+	switch name {
+	case "any":
+		return false, nil
+	case "all", "none":
+		return true, nil
+	}
+	return ret, nil
+}
+
+/*
+Returns the elements of the array (or slice) [collection]. Nil has no elements.
+*/
+func quantifiedElements(name string, collection interface{}) ([]interface{}, error) {
+
+	if collection == nil {
+		return nil, nil
+	}
+
+	if elements, ok := collection.([]interface{}); ok {
+		return elements, nil
+	}
+
+	value := reflect.ValueOf(collection)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, newQuantifierTypeError(collection, nil, fmt.Sprintf("Value '%v' cannot be used with the quantifier '%s', it is not an array", collection, name))
+	}
+
+	ret := make([]interface{}, value.Len())
+	for i := range ret {
+		ret[i] = value.Index(i).Interface()
+	}
+	return ret, nil
+}
+
+/*
+Returns [parameters] in which `#` is the given [item].
+*/
+func withItem(parameters Parameters, item interface{}) *sanitizedParameters {
+
+	sanitized, ok := parameters.(*sanitizedParameters)
+	if !ok {
+		sanitized = &sanitizedParameters{orig: parameters}
+	}
+
+	ret := *sanitized
+	ret.item = item
+	ret.hasItem = true
+	return &ret
+}
+
+/*
+Returns [parameters] in which `#` isn't anything, such as for a quantifier within the expression of another.
+*/
+func withoutItem(parameters *sanitizedParameters) *sanitizedParameters {
+
+	ret := *parameters
+	ret.item = nil
+	ret.hasItem = false
+	return &ret
+}
+
+/*
+The value of `#`, which is the element a quantifier is currently looking at.
+*/
+func itemStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	if sanitized, ok := parameters.(*sanitizedParameters); ok && sanitized.hasItem {
+		return sanitized.item, nil
+	}
+	return nil, errors.New("'#' can only be used within a quantifier, such as any(items, # > 1)")
+}
+
+func newQuantifierTypeError(collection interface{}, value interface{}, message string) error {
+
+	return &TypeMismatchError{
+		Operator:  QUANTIFY,
+		Left:      collection,
+		Right:     value,
+		LeftType:  reflect.TypeOf(collection),
+		RightType: reflect.TypeOf(value),
+		message:   message,
+	}
+}
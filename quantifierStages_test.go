@@ -0,0 +1,186 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+/*
+Represents a test of evaluating an expression which uses a quantifier.
+*/
+type QuantifierStageTest struct {
+	Name     string
+	Input    string
+	Numerics NumericMode
+	Expected interface{}
+}
+
+func TestQuantifierStages(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"price": 5},
+			map[string]interface{}{"price": 20},
+		},
+		"tags":    []string{"new", "sale"},
+		"users":   []dummyParameter{dummyParameterInstance, {String: "other", Int: 7}},
+		"minimum": 10,
+		"nothing": nil,
+	}
+
+	quantifierTests := []QuantifierStageTest{
+
+		{
+			Name:     "Any",
+			Input:    "any(items, #.price > 10)",
+			Expected: true,
+		},
+		{
+			Name:     "Any unmatched",
+			Input:    "any(items, #.price > 100)",
+			Expected: false,
+		},
+		{
+			Name:     "All",
+			Input:    "all(tags, # != 'blocked')",
+			Expected: true,
+		},
+		{
+			Name:     "All unmatched",
+			Input:    "all(items, #.price > minimum)",
+			Expected: false,
+		},
+		{
+			Name:     "None",
+			Input:    "none(tags, # == 'sale')",
+			Expected: false,
+		},
+		{
+			Name:     "Filter",
+			Input:    "filter(users, #.Int > 100)[0].String",
+			Expected: "string!",
+		},
+		{
+			Name:     "Filter list literal",
+			Input:    "filter({1, 2, 3}, # >= 2)",
+			Expected: []interface{}{2.0, 3.0},
+		},
+		{
+			Name:     "Map",
+			Input:    "map(users, #.String)",
+			Expected: []interface{}{"string!", "other"},
+		},
+		{
+			Name:     "Map index",
+			Input:    "map(items, #['price'] * 2)",
+			Expected: []interface{}{10.0, 40.0},
+		},
+		{
+			Name:     "Empty array",
+			Input:    "all({}, false) && !any({}, true)",
+			Expected: true,
+		},
+		{
+			Name:     "Nil array",
+			Input:    "none(nothing, true)",
+			Expected: true,
+		},
+		{
+			Name:     "Nested quantifiers",
+			Input:    "any(users, any(#.Map['IntArray'], # == 3))",
+			Expected: true,
+		},
+		{
+			Name:     "Chained quantifiers",
+			Input:    "map(filter({1, 2, 3}, # > 1), # * 10)",
+			Expected: []interface{}{20.0, 30.0},
+		},
+		{
+			Name:     "Within a clause",
+			Input:    "(any(tags, # == 'new') || false) && minimum > 1",
+			Expected: true,
+		},
+		{
+			Name:     "Membership",
+			Input:    "'sale' in filter(tags, # != 'new')",
+			Expected: true,
+		},
+		{
+			Name:     "Quantifier name as a variable",
+			Input:    "[any] == nil",
+			Expected: true,
+		},
+		{
+			Name:     "Integer map",
+			Input:    "map(items, #.price + 1)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: []interface{}{int64(6), int64(21)},
+		},
+		{
+			Name:     "Integer filter",
+			Input:    "len(filter(users, #.Int < minimum)) == 1",
+			Numerics: INTEGER_NUMERICS,
+			Expected: true,
+		},
+		{
+			Name:     "Decimal any",
+			Input:    "any(items, #.price * 0.1 == 2)",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: true,
+		},
+	}
+
+	for _, quantifierTest := range quantifierTests {
+
+		options := ExpressionOptions{
+			Numerics:               quantifierTest.Numerics,
+			Functions:              StandardFunctionDefinitions(),
+			MissingParametersAsNil: true,
+		}
+
+		expression, err := NewEvaluableExpressionWithOptions(quantifierTest.Input, nil, options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", quantifierTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", quantifierTest.Name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, quantifierTest.Expected) {
+			test.Errorf("Test '%s' failed: expected %v (%T), got %v (%T)", quantifierTest.Name, quantifierTest.Expected, quantifierTest.Expected, result, result)
+		}
+	}
+}
+
+func TestQuantifierStageFailures(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"items": []interface{}{1, 2},
+		"count": 3,
+		"foo":   dummyParameterInstance,
+	}
+
+	mismatched := []string{
+		"any(count, true)",
+		"map(foo, #)",
+		"all(items, # + 1)",
+		"filter(items, 'yes')",
+	}
+
+	for _, input := range mismatched {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		var typeError *TypeMismatchError
+		_, err := expression.Evaluate(parameters)
+
+		if !errors.As(err, &typeError) || typeError.Operator != QUANTIFY {
+			test.Errorf("Expected '%s' to fail with a TypeMismatchError, got: %v", input, err)
+		}
+	}
+}
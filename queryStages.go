@@ -0,0 +1,247 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+/*
+Plans this expression's stages as they were written, for writing it as a query.
+*/
+func (e EvaluableExpression) queryStages() (*evaluationStage, error) {
+
+	// without tokens (such as after `CleanupTokens`, or for a residual which can't be written), only the evaluated stages are left.
+	if len(e.tokens) == 0 {
+		return e.evaluationStages, nil
+	}
+
+	// times are planned as the unix times they're compared with when evaluated,
+	// so they're planned as strings instead, which keeps them as the times they were written as.
+	tokens := make([]ExpressionToken, len(e.tokens))
+	for i, token := range e.tokens {
+		if token.Kind == TIME {
+			token.Kind = STRING
+		}
+		tokens[i] = token
+	}
+
+	return planSyntaxTree(tokens, e.positions)
+}
+
+/*
+Returns the field and the value being compared by the comparison [stage], along with its operator.
+Comparisons with a literal on the left are turned around, so that `1 < x` is given as `x > 1`,
+except for patterns, which have to be on the right.
+*/
+func queryComparison(stage *evaluationStage) (OperatorSymbol, *evaluationStage, *evaluationStage) {
+
+	symbol := stage.symbol
+	field, value := stage.leftStage, stage.rightStage
+
+	if !isQueryLiteral(field) || isQueryLiteral(value) || symbol == REQ || symbol == NREQ {
+		return symbol, field, value
+	}
+
+	switch symbol {
+	case GT:
+		symbol = LT
+	case LT:
+		symbol = GT
+	case GTE:
+		symbol = LTE
+	case LTE:
+		symbol = GTE
+	}
+	return symbol, value, field
+}
+
+/*
+Returns the name of the field that [stage] refers to, with accessors in dot notation.
+[target] names the kind of query being written, for errors.
+*/
+func queryField(stage *evaluationStage, target string) (string, error) {
+
+	for stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if isQueryLiteral(stage) {
+		return "", fmt.Errorf("comparisons between two literals are unsupported in %s", target)
+	}
+
+	var field string
+
+	switch stage.symbol {
+
+	case VALUE:
+		field = stage.name
+
+	case ACCESS:
+		if stage.rightStage != nil {
+			return "", fmt.Errorf("the method call '%s' is unsupported in %s", strings.Join(stage.path, "."), target)
+		}
+		field = strings.Join(stage.path, ".")
+
+	default:
+		return "", fmt.Errorf("only fields can be compared in %s, not %s", target, queryConstruct(stage))
+	}
+
+	// fields are written as the keys of query documents, where a name like `$where` would be taken as an operator.
+	for _, segment := range strings.Split(field, ".") {
+		if strings.HasPrefix(segment, "$") || strings.ContainsRune(segment, 0) {
+			return "", fmt.Errorf("the field '%s' is not a valid field name in %s", field, target)
+		}
+	}
+	return field, nil
+}
+
+/*
+Returns the value of the literal [stage], which a field is being compared with.
+*/
+func queryValue(stage *evaluationStage, target string) (interface{}, error) {
+
+	for stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if value, ok := queryLiteralValue(stage); ok {
+		return value, nil
+	}
+
+	switch stage.symbol {
+	case VALUE, ACCESS:
+		return nil, fmt.Errorf("comparisons between two fields are unsupported in %s", target)
+	}
+	return nil, fmt.Errorf("fields can only be compared with literals in %s, not %s", target, queryConstruct(stage))
+}
+
+/*
+Returns the value of [stage] if it's a literal, including a negative number (which is planned as a negated literal),
+and whether it is one.
+*/
+func queryLiteralValue(stage *evaluationStage) (interface{}, bool) {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if stage == nil {
+		return nil, false
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return value, true
+
+	case NEGATE:
+		value, ok := queryLiteralValue(stage.rightStage)
+		if !ok {
+			return nil, false
+		}
+
+		switch value := value.(type) {
+		case *big.Rat:
+			return new(big.Rat).Neg(value), true
+		case int64, uint64, float64:
+			ret, _ := integerNegateStage(nil, value, nil)
+			return ret, true
+		}
+	}
+	return nil, false
+}
+
+func isQueryLiteral(stage *evaluationStage) bool {
+
+	_, ok := queryLiteralValue(stage)
+	return ok
+}
+
+/*
+Returns the values of the literal elements of a list literal, comma-separated array, or known array.
+*/
+func queryValues(stage *evaluationStage, target string) ([]interface{}, error) {
+
+	switch stage.symbol {
+
+	case NOOP:
+		return queryValues(stage.rightStage, target)
+
+	case LIST_LITERAL:
+		if stage.leftStage == nil {
+			return []interface{}{}, nil
+		}
+		return queryValues(stage.leftStage, target)
+
+	case SEPARATE:
+		ret, err := queryValues(stage.leftStage, target)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := queryValue(stage.rightStage, target)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, right), nil
+	}
+
+	value, err := queryValue(stage, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if values, ok := value.([]interface{}); ok {
+		return values, nil
+	}
+	return []interface{}{value}, nil
+}
+
+/*
+Describes what [stage] is, for errors about parts of an expression that can't be written in a query.
+Operators are named by their symbol, but many stages (such as list literals) have no symbol of their own.
+*/
+func queryConstruct(stage *evaluationStage) string {
+
+	switch stage.symbol {
+	case FUNCTIONAL:
+		return "a function call"
+	case ACCESS:
+		return "an accessor"
+	case SEPARATE:
+		return "a comma-separated list"
+	case LIST_LITERAL:
+		return "a list"
+	case INDEX_ACCESS:
+		return "an index"
+	case QUANTIFY:
+		return "a quantifier"
+	case ITEM_VALUE:
+		return "'#'"
+	}
+	return fmt.Sprintf("the result of '%s'", stage.symbol)
+}
+
+/*
+Returns the literal [value] as it's given in a query document.
+Decimals have no equivalent in JSON, or in BSON without the driver's own types, so they become floats.
+*/
+func queryLiteral(value interface{}) interface{} {
+
+	switch value := value.(type) {
+
+	case *big.Rat:
+		float, _ := value.Float64()
+		return float
+
+	case []interface{}:
+		ret := make([]interface{}, len(value))
+		for i, element := range value {
+			ret[i] = queryLiteral(element)
+		}
+		return ret
+	}
+	return value
+}
@@ -1,18 +1,72 @@
 package govaluate
 
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
 // sanitizedParameters is a wrapper for Parameters that does sanitization as
-// parameters are accessed.
+// parameters are accessed. It also carries the context of the evaluation in progress,
+// so that stages which call out to user code can hand it along.
 type sanitizedParameters struct {
-	orig Parameters
+	orig     Parameters
+	ctx      context.Context
+	numerics NumericMode
+
+	// if true, parameters which are missing (see MissingParameterError) are nil.
+	missingAsNil bool
+
+	// how accessors and indexes find the fields of structs.
+	fields fieldNaming
+
+	// the time zone that date literals are parsed in, which numbers given as times are converted to. If nil, `time.Local` is used.
+	location *time.Location
+
+	// the element a quantifier is looking at, which `#` refers to, if [hasItem] is true.
+	item    interface{}
+	hasItem bool
 }
 
 func (p sanitizedParameters) Get(key string) (interface{}, error) {
 	value, err := p.orig.Get(key)
 	if err != nil {
+		var missing *MissingParameterError
+		if p.missingAsNil && errors.As(err, &missing) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	return castToFloat64(value), nil
+	return p.sanitize(value), nil
+}
+
+func (p sanitizedParameters) sanitize(value interface{}) interface{} {
+	switch p.numerics {
+	case INTEGER_NUMERICS:
+		return castToInteger(value)
+	case DECIMAL_NUMERICS:
+		return castToDecimal(value)
+	}
+	return castToFloat64(value)
+}
+
+// locationFor returns the time zone that numbers are converted to times in, for the evaluation that [parameters] are from.
+func locationFor(parameters Parameters) *time.Location {
+	if sanitized, ok := parameters.(*sanitizedParameters); ok && sanitized.location != nil {
+		return sanitized.location
+	}
+	return time.Local
+}
+
+// sanitizeFor sanitizes [value] the same way the given [parameters] sanitize their own values,
+// for values which are reached through parameters (such as the results of accessors), or returned by functions.
+func sanitizeFor(parameters Parameters, value interface{}) interface{} {
+	if sanitized, ok := parameters.(*sanitizedParameters); ok {
+		return sanitized.sanitize(value)
+	}
+	return castToFloat64(value)
 }
 
 func castToFloat64(value interface{}) interface{} {
@@ -41,3 +95,47 @@ func castToFloat64(value interface{}) interface{} {
 
 	return value
 }
+
+// castToInteger converts integers of any width to int64, or to uint64 if they're too large for an int64.
+// float32 values are converted to float64.
+func castToInteger(value interface{}) interface{} {
+	switch value := value.(type) {
+	case uint8:
+		return int64(value)
+	case uint16:
+		return int64(value)
+	case uint32:
+		return int64(value)
+	case uint64:
+		if value > math.MaxInt64 {
+			return value
+		}
+		return int64(value)
+	case uint:
+		if uint64(value) > math.MaxInt64 {
+			return uint64(value)
+		}
+		return int64(value)
+	case int8:
+		return int64(value)
+	case int16:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case int:
+		return int64(value)
+	case float32:
+		return float64(value)
+	}
+
+	return value
+}
+
+// contextOf returns the context that the given [parameters] are being evaluated with,
+// or context.Background() if they aren't part of a context-bound evaluation.
+func contextOf(parameters Parameters) context.Context {
+	if sanitized, ok := parameters.(*sanitizedParameters); ok && sanitized.ctx != nil {
+		return sanitized.ctx
+	}
+	return context.Background()
+}
@@ -1,7 +1,10 @@
 package govaluate
 
 import (
+	"database/sql"
+	"reflect"
 	"testing"
+	"time"
 )
 
 /*
@@ -154,7 +157,19 @@ func TestSQLSerialization(test *testing.T) {
 
 			Name:     "Membership operator",
 			Input:    "foo IN (1, 2, 3)",
-			Expected: "[foo] in ( 1 , 2 , 3 )",
+			Expected: "[foo] IN (1, 2, 3)",
+		},
+		{
+
+			Name:     "List membership",
+			Input:    "foo in {1, 2}",
+			Expected: "[foo] IN (1, 2)",
+		},
+		{
+
+			Name:     "Empty list membership",
+			Input:    "foo in {}",
+			Expected: "1 = 0",
 		},
 		{
 
@@ -162,32 +177,60 @@ func TestSQLSerialization(test *testing.T) {
 			Input:    "foo ?? bar",
 			Expected: "COALESCE([foo], [bar])",
 		},
-		/*
-			// Ternaries don't work yet, because the outputter is not yet sophisticated enough to produce them.
-			QueryTest{
+		{
+
+			Name:     "Full ternary",
+			Input:    "[foo] == 5 ? 1 : 2",
+			Expected: "CASE WHEN [foo] = 5 THEN 1 ELSE 2 END",
+		},
+		{
+
+			Name:     "Half ternary",
+			Input:    "[foo] == 5 ? 1",
+			Expected: "CASE WHEN [foo] = 5 THEN 1 END",
+		},
+		{
+
+			Name:     "Full ternary with implicit bool",
+			Input:    "[foo] ? 1 : 2",
+			Expected: "CASE WHEN [foo] THEN 1 ELSE 2 END",
+		},
+		{
+
+			Name:     "Nested ternary",
+			Input:    "(foo > 1 ? 'big' : 'small') == bar",
+			Expected: "( CASE WHEN [foo] > 1 THEN 'big' ELSE 'small' END ) = [bar]",
+		},
+		{
 
-				Name:     "Full ternary",
-				Input:    "[foo] == 5 ? 1 : 2",
-				Expected: "IF([foo] = 5, 1, 2)",
-			},
-			QueryTest{
+			Name:     "Accessor",
+			Input:    "orders.total > 10 && orders.customer.name == 'Ada'",
+			Expected: "[orders].[total] > 10 AND [orders].[customer].[name] = 'Ada'",
+		},
+		{
 
-				Name:     "Half ternary",
-				Input:    "[foo] == 5 ? 1",
-				Expected: "IF([foo] = 5, 1)",
-			},
-			QueryTest{
+			Name:     "Membership of parameters",
+			Input:    "foo in (bar, baz.qux, 'x')",
+			Expected: "[foo] IN ([bar], [baz].[qux], 'x')",
+		},
+		{
 
-				Name:     "Full ternary with implicit bool",
-				Input:    "[foo] ? 1 : 2",
-				Expected: "IF([foo] = 0, 1, 2)",
-			},
-			QueryTest{
+			Name:     "Exponent after parenthesis",
+			Input:    "(foo + 1) ** 2 > 4",
+			Expected: "POW(( [foo] + 1 ), 2) > 4",
+		},
+		{
 
-				Name:     "Half ternary with implicit bool",
-				Input:    "[foo] ? 1",
-				Expected: "IF([foo] = 0, 1)",
-			},*/
+			Name:     "Nil comparisons",
+			Input:    "foo == nil && bar != null",
+			Expected: "[foo] IS NULL AND [bar] IS NOT NULL",
+		},
+		{
+
+			Name:     "Nil value",
+			Input:    "foo ?? nil",
+			Expected: "COALESCE([foo], NULL)",
+		},
 		{
 
 			Name:     "Regex equals",
@@ -200,11 +243,88 @@ func TestSQLSerialization(test *testing.T) {
 			Input:    "'foo' !~ '[fF][oO]+'",
 			Expected: "'foo' NOT RLIKE '[fF][oO]+'",
 		},
+		{
+
+			Name:     "Quoted string",
+			Input:    `name == 'it\'s' || name == '\'; DROP TABLE users; --'`,
+			Expected: "[name] = 'it''s' OR [name] = '''; DROP TABLE users; --'",
+		},
 	}
 
 	runQueryTests(testCases, test)
 }
 
+/*
+Represents a test of creating a SQL query with placeholders, and the arguments for them.
+*/
+type QueryArgumentsTest struct {
+	Name      string
+	Input     string
+	Style     SQLPlaceholderStyle
+	Expected  string
+	Arguments []interface{}
+}
+
+func TestSQLSerializationWithArgs(test *testing.T) {
+
+	date := time.Date(2014, time.January, 2, 0, 0, 0, 0, time.Local)
+
+	testCases := []QueryArgumentsTest{
+
+		{
+			Name:      "Question marks",
+			Input:     "name == 'bob' && age > 30",
+			Style:     QUESTION_PLACEHOLDERS,
+			Expected:  "[name] = ? AND [age] > 30",
+			Arguments: []interface{}{"bob"},
+		},
+		{
+			Name:      "Numbered",
+			Input:     `(name == 'it\'s' || name != 'x') && created > '2014-01-02'`,
+			Style:     DOLLAR_PLACEHOLDERS,
+			Expected:  "( [name] = $1 OR [name] <> $2 ) AND [created] > $3",
+			Arguments: []interface{}{"it's", "x", date},
+		},
+		{
+			Name:      "Named",
+			Input:     "name =~ '^b' || (nickname ?? 'none') == 'bo'",
+			Style:     NAMED_PLACEHOLDERS,
+			Expected:  "[name] RLIKE :p1 OR ( COALESCE([nickname], :p2) ) = :p3",
+			Arguments: []interface{}{sql.Named("p1", "^b"), sql.Named("p2", "none"), sql.Named("p3", "bo")},
+		},
+		{
+			Name:      "Without literals",
+			Input:     "active && deleted == nil",
+			Style:     DOLLAR_PLACEHOLDERS,
+			Expected:  "[active] AND [deleted] IS NULL",
+			Arguments: nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		query, arguments, err := expression.ToSQLQueryWithArgs(testCase.Style)
+		if err != nil {
+			test.Errorf("Test '%s' failed to create query: %s", testCase.Name, err)
+			continue
+		}
+
+		if query != testCase.Expected {
+			test.Errorf("Test '%s' created '%s', expected '%s'", testCase.Name, query, testCase.Expected)
+		}
+
+		if !reflect.DeepEqual(arguments, testCase.Arguments) {
+			test.Errorf("Test '%s' gave arguments %v, expected %v", testCase.Name, arguments, testCase.Arguments)
+		}
+	}
+}
+
 func runQueryTests(testCases []QueryTest, test *testing.T) {
 
 	var expression *EvaluableExpression
@@ -245,3 +365,173 @@ func runQueryTests(testCases []QueryTest, test *testing.T) {
 		}
 	}
 }
+
+/*
+Represents a test of creating a SQL query for a particular database.
+*/
+type QueryDialectTest struct {
+	Name     string
+	Input    string
+	Dialect  SQLDialect
+	Expected string
+}
+
+func TestSQLDialects(test *testing.T) {
+
+	testCases := []QueryDialectTest{
+
+		{
+			Name:     "MySQL",
+			Input:    "active == true && name =~ '^b' && [my`col] ** 2 > score % 3 && created > '2014-01-02 03:04:05'",
+			Dialect:  MySQLDialect,
+			Expected: "`active` = TRUE AND `name` REGEXP '^b' AND POW(`my``col`, 2) > MOD(`score`, 3) AND `created` > '2014-01-02 03:04:05'",
+		},
+		{
+			Name:     "PostgreSQL",
+			Input:    "active != false && name !~ '^b' && [my\"col] ** 2 > score % 3",
+			Dialect:  PostgreSQLDialect,
+			Expected: `"active" <> FALSE AND "name" !~ '^b' AND POWER("my""col", 2) > MOD("score", 3)`,
+		},
+		{
+			Name:     "SQLite",
+			Input:    "active == true && name =~ '^b' && size ** 2 > score % 3",
+			Dialect:  SQLiteDialect,
+			Expected: `"active" = 1 AND "name" REGEXP '^b' AND POWER("size", 2) > "score" % 3`,
+		},
+		{
+			Name:     "SQL Server",
+			Input:    "active == false && [my\\]col] ** 2 > score % 3",
+			Dialect:  SQLServerDialect,
+			Expected: "[active] = 0 AND POWER([my]]col], 2) > [score] % 3",
+		},
+		{
+			Name:     "Default",
+			Input:    "active == true && size ** 2 > score % 3",
+			Dialect:  DefaultSQLDialect,
+			Expected: "[active] = 1 AND POW([size], 2) > MOD([score], 3)",
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		query, err := expression.ToSQLQueryFor(testCase.Dialect)
+		if err != nil {
+			test.Errorf("Test '%s' failed to create query: %s", testCase.Name, err)
+			continue
+		}
+
+		if query != testCase.Expected {
+			test.Errorf("Test '%s' created '%s', expected '%s'", testCase.Name, query, testCase.Expected)
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("name =~ '^b' && created > '2014-01-02'")
+
+	query, arguments, err := expression.ToSQLQueryWithArgsFor(PostgreSQLDialect)
+	if err != nil || query != `"name" ~ $1 AND "created" > $2` || len(arguments) != 2 {
+		test.Errorf("Unexpected PostgreSQL query '%s' with arguments %v: %v", query, arguments, err)
+	}
+
+	_, err = expression.ToSQLQueryFor(SQLServerDialect)
+	if err == nil {
+		test.Errorf("Expected patterns to be unsupported for SQL Server")
+	}
+}
+
+func TestSQLFunctions(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"lower": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+		"between": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+		"now": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	dialect := PostgreSQLDialect
+	dialect.Functions = map[string]SQLFunction{
+		"lower": SQLFunctionCall("LOWER"),
+		"now":   SQLFunctionCall("NOW"),
+		"between": func(arguments []string) (string, error) {
+			return arguments[0] + " BETWEEN " + arguments[1] + " AND " + arguments[2], nil
+		},
+	}
+
+	testCases := []QueryDialectTest{
+
+		{
+			Name:     "Mapped name",
+			Input:    "lower(name) == 'ada'",
+			Expected: `LOWER("name") = 'ada'`,
+		},
+		{
+			Name:     "Custom function",
+			Input:    "between(age, 18, 65) && created < now()",
+			Expected: `"age" BETWEEN 18 AND 65 AND "created" < NOW()`,
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %s", testCase.Name, err)
+			continue
+		}
+
+		query, err := expression.ToSQLQueryFor(dialect)
+		if err != nil {
+			test.Errorf("Test '%s' failed to create query: %s", testCase.Name, err)
+			continue
+		}
+
+		if query != testCase.Expected {
+			test.Errorf("Test '%s' created '%s', expected '%s'", testCase.Name, query, testCase.Expected)
+		}
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("lower(name) == 'ada'", functions)
+
+	_, err := expression.ToSQLQuery()
+	if err == nil {
+		test.Errorf("Expected functions without a mapping to be unsupported")
+	}
+}
+
+func TestSQLFailures(test *testing.T) {
+
+	failures := []string{
+		"user.Name() == 'ada'",
+		"any(users, # > 1)",
+		"users[0] == 1",
+		"x == {1, 2}",
+		"{1, 2} != x",
+		"x == (1, 2)",
+	}
+
+	options := ExpressionOptions{Functions: StandardFunctionDefinitions()}
+
+	for _, input := range failures {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Failed to parse '%s': %s", input, err)
+			continue
+		}
+
+		_, err = expression.ToSQLQuery()
+		if err == nil {
+			test.Errorf("Expected '%s' to be unsupported in SQL", input)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package govaluate
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -81,13 +82,13 @@ func init() {
 		validSymbols:    prefixSymbols,
 		validKinds:      []TokenKind{PREFIX},
 		typeErrorFormat: prefixErrorFormat,
-		nextRight:       planFunction,
+		nextRight:       planIndex,
 	})
 	planExponential = makePrecedentFromPlanner(&precedencePlanner{
 		validSymbols:    exponentialSymbolsS,
 		validKinds:      []TokenKind{MODIFIER},
 		typeErrorFormat: modifierErrorFormat,
-		next:            planFunction,
+		next:            planIndex,
 	})
 	planMultiplicative = makePrecedentFromPlanner(&precedencePlanner{
 		validSymbols:    multiplicativeSymbols,
@@ -178,9 +179,87 @@ Creates a `evaluationStageList` object which represents an execution plan (or tr
 which is used to completely evaluate a set of tokens at evaluation-time.
 The three stages of evaluation can be thought of as parsing strings to tokens, then tokens to a stage list, then evaluation with parameters.
 */
-func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
+func planStages(tokens []ExpressionToken, positions *tokenPositions, options ExpressionOptions) (*evaluationStage, error) {
+
+	stage, err := planSyntaxTree(tokens, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkFunctionCalls(stage, positions, options.Functions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkItems(stage, positions, false)
+	if err != nil {
+		return nil, err
+	}
+
+	useArrayArguments(stage, options.Functions)
+	useNumericMode(stage, options.Numerics)
+	useTimeStages(stage)
+
+	stage = elideLiterals(stage)
+	return stage, nil
+}
+
+/*
+Checks that every call to a function with a definition is given a number of arguments that its signature allows.
+*/
+func checkFunctionCalls(stage *evaluationStage, positions *tokenPositions, definitions map[string]FunctionDefinition) error {
+
+	if stage == nil || len(definitions) == 0 {
+		return nil
+	}
+
+	if stage.symbol == FUNCTIONAL {
+
+		definition, found := definitions[stage.name]
+		if found {
+
+			message := definition.arityError(stage.name, len(argumentStages(stage.rightStage)))
+			if message != "" {
+				return positions.errorAt(stage.tokenIndex, PARSE_ARGUMENT_COUNT, message)
+			}
+		}
+	}
+
+	err := checkFunctionCalls(stage.leftStage, positions, definitions)
+	if err != nil {
+		return err
+	}
+	return checkFunctionCalls(stage.rightStage, positions, definitions)
+}
+
+/*
+Checks that `#` is only used within the expression of a quantifier, where it's [bound] to an element.
+*/
+func checkItems(stage *evaluationStage, positions *tokenPositions, bound bool) error {
+
+	if stage == nil {
+		return nil
+	}
+
+	if stage.symbol == ITEM_VALUE && !bound {
+		return positions.errorAt(stage.tokenIndex, PARSE_UNBOUND_ITEM, "'#' can only be used within a quantifier, such as any(items, # > 1)")
+	}
+
+	err := checkItems(stage.leftStage, positions, bound)
+	if err != nil {
+		return err
+	}
+	return checkItems(stage.rightStage, positions, bound || stage.symbol == QUANTIFY)
+}
+
+/*
+Plans the given [tokens] into a tree of stages which mirrors the structure of the expression exactly,
+without any of the optimizations `planStages` makes afterwards (such as folding constant sub-expressions).
+*/
+func planSyntaxTree(tokens []ExpressionToken, positions *tokenPositions) (*evaluationStage, error) {
 
 	stream := newTokenStream(tokens)
+	stream.positions = positions
 
 	stage, err := planTokens(stream)
 	if err != nil {
@@ -191,11 +270,30 @@ func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
 	// while we're now fully-planned, we now need to re-order same-precedence operators.
 	// this could probably be avoided with a different planning method
 	reorderStages(stage)
-
-	stage = elideLiterals(stage)
+	planSeparators(stage)
 	return stage, nil
 }
 
+/*
+Recurses through the entire tree, making each comma-separated list build a single array of its elements.
+Once reordered, the first separator of a list is the deepest, and starts the array; every other separator appends to it.
+This is decided by the shape of the tree rather than by the values being separated,
+so that arrays given as elements (such as list literals, or array parameters) remain single elements.
+*/
+func planSeparators(root *evaluationStage) {
+
+	if root == nil {
+		return
+	}
+
+	planSeparators(root.leftStage)
+	planSeparators(root.rightStage)
+
+	if root.symbol == SEPARATE && root.leftStage != nil && root.leftStage.symbol == SEPARATE {
+		root.operator = appendSeparatorStage
+	}
+}
+
 func planTokens(stream *tokenStream) (*evaluationStage, error) {
 
 	if !stream.hasNext() {
@@ -240,6 +338,7 @@ func planPrecedenceLevel(
 	if stream.hasNext() {
 
 		token = stream.next()
+		tokenIndex := stream.index - 1
 
 		if len(validKinds) > 0 {
 
@@ -288,12 +387,71 @@ func planPrecedenceLevel(
 			rightTypeCheck:  checks.right,
 			typeCheck:       checks.combined,
 			typeErrorFormat: typeErrorFormat,
+			tokenIndex:      tokenIndex,
 		}, nil
 	}
 
 	return rewind()
 }
 
+/*
+Plans a value followed by any number of indexes and fields, such as `items[0].Name`.
+Each is a stage of its own, which has whatever it indexes on the left, and the key on the right.
+*/
+func planIndex(stream *tokenStream) (*evaluationStage, error) {
+
+	ret, err := planFunction(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	for stream.hasNext() {
+
+		token := stream.next()
+		tokenIndex := stream.index - 1
+
+		switch token.Kind {
+
+		case INDEX:
+			key, err := planTokens(stream)
+			if err != nil {
+				return nil, err
+			}
+
+			// advance past the INDEX_CLOSE token. We know that it's an INDEX_CLOSE, because at parse-time we check for unbalanced brackets.
+			stream.next()
+
+			ret = &evaluationStage{
+				symbol:     INDEX_ACCESS,
+				leftStage:  ret,
+				rightStage: key,
+				operator:   indexStage,
+				tokenIndex: tokenIndex,
+			}
+
+		case FIELD:
+			// fields are indexes by their name, which is kept so that they're written the same way.
+			for _, name := range token.Value.([]string) {
+
+				ret = &evaluationStage{
+					symbol:     INDEX_ACCESS,
+					leftStage:  ret,
+					rightStage: newLiteralStage(name),
+					operator:   indexStage,
+					name:       name,
+					tokenIndex: tokenIndex,
+				}
+			}
+
+		default:
+			stream.rewind()
+			return ret, nil
+		}
+	}
+
+	return ret, nil
+}
+
 /*
 A special case where functions need to be of higher precedence than values, and need a special wrapped execution stage operator.
 */
@@ -305,22 +463,128 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 
 	token = stream.next()
 
+	if token.Kind == QUANTIFIER {
+		stream.rewind()
+		return planQuantifier(stream)
+	}
+
 	if token.Kind != FUNCTION {
 		stream.rewind()
 		return planAccessor(stream)
 	}
 
+	tokenIndex := stream.index - 1
+	name := stream.positions.text(tokenIndex)
+
 	rightStage, err = planAccessor(stream)
 	if err != nil {
 		return nil, err
 	}
 
+	var operator evaluationOperator
+
+	switch function := token.Value.(type) {
+	case ContextExpressionFunction:
+		operator = makeContextFunctionStage(name, function)
+	default:
+		operator = makeFunctionStage(name, function.(ExpressionFunction))
+	}
+
+	if isStandardFunction(token.Value) {
+		operator = sanitizeResultStage(operator)
+	}
+	if takesTime(token.Value) {
+		operator = makeTimeArgumentStage(operator)
+	}
+
 	return &evaluationStage{
 
 		symbol:          FUNCTIONAL,
 		rightStage:      rightStage,
-		operator:        makeFunctionStage(token.Value.(ExpressionFunction)),
+		operator:        operator,
 		typeErrorFormat: "Unable to run function '%v': %v",
+		name:            name,
+		keepArrays:      keepsArrays(token.Value),
+		tokenIndex:      tokenIndex,
+	}, nil
+}
+
+/*
+Makes the call whose parenthesized arguments are [clause] give the array it's given as its only argument as it is,
+rather than spread into the function's arguments. Calls with several arguments already give each one as it is.
+*/
+func keepArrayArgument(clause *evaluationStage) {
+
+	if clause == nil || clause.symbol != NOOP || clause.rightStage == nil || clause.rightStage.symbol == SEPARATE {
+		return
+	}
+	clause.operator = ensureSliceStage(noopStageRight)
+}
+
+/*
+Returns whether the parenthesized arguments [clause] hold a single list literal.
+*/
+func isListArgument(clause *evaluationStage) bool {
+
+	if clause == nil || clause.symbol != NOOP {
+		return false
+	}
+
+	argument := clause.rightStage
+	for argument != nil && argument.symbol == NOOP {
+		argument = argument.rightStage
+	}
+	return argument != nil && argument.symbol == LIST_LITERAL
+}
+
+/*
+Recurses through the entire tree, making each call to a function give an array as its only argument as it is, rather than spread into
+the function's arguments, where the function keeps arrays (as its definition, if any, may say) or the argument is a list literal.
+This is done once the tree is reordered, since reordering moves the contents of stages between them.
+*/
+func useArrayArguments(stage *evaluationStage, definitions map[string]FunctionDefinition) {
+
+	if stage == nil {
+		return
+	}
+
+	// a list literal is always one array, however many elements it has, so it's never spread into several arguments.
+	if stage.symbol == FUNCTIONAL && (stage.keepArrays || definitions[stage.name].KeepArrays || isListArgument(stage.rightStage)) {
+		keepArrayArgument(stage.rightStage)
+	}
+
+	useArrayArguments(stage.leftStage, definitions)
+	useArrayArguments(stage.rightStage, definitions)
+}
+
+/*
+Plans a quantifier, such as `any(items, #.price > 10)`.
+The array it looks at is on the left, and the expression it runs for each element is on the right.
+*/
+func planQuantifier(stream *tokenStream) (*evaluationStage, error) {
+
+	token := stream.next()
+	tokenIndex := stream.index - 1
+	name := token.Value.(string)
+
+	// the arguments are planned the same way a function's are, and then taken apart.
+	clause, err := planAccessor(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := clause.rightStage
+	if arguments == nil || arguments.symbol != SEPARATE || arguments.rightStage == nil || arguments.rightStage.symbol == SEPARATE {
+		errorMsg := fmt.Sprintf("Quantifier '%s' takes two arguments, an array and an expression of its elements, such as %s(items, # > 1)", name, name)
+		return nil, stream.positions.errorAt(tokenIndex, PARSE_ARGUMENT_COUNT, errorMsg)
+	}
+
+	return &evaluationStage{
+		symbol:     QUANTIFY,
+		leftStage:  arguments.leftStage,
+		rightStage: arguments.rightStage,
+		name:       name,
+		tokenIndex: tokenIndex,
 	}, nil
 }
 
@@ -341,6 +605,8 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 		return planValue(stream)
 	}
 
+	tokenIndex := stream.index - 1
+
 	// check if this is meant to be a function or a field.
 	// fields have a clause next to them, functions do not.
 	// if it's a function, parse the arguments. Otherwise leave the right stage null.
@@ -360,15 +626,51 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 		}
 	}
 
+	path, optional := accessorPath(token.Value.([]string))
+
 	return &evaluationStage{
 
 		symbol:          ACCESS,
 		rightStage:      rightStage,
-		operator:        makeAccessorStage(token.Value.([]string)),
+		operator:        makeAccessorStage(path, optional),
 		typeErrorFormat: "Unable to access parameter field or method '%v': %v",
+		path:            path,
+		optional:        optional,
+		tokenIndex:      tokenIndex,
 	}, nil
 }
 
+/*
+Splits the path of an accessor token into its names, and whether each of them is accessed optionally,
+which the lexer marks with a leading '?'. The second return is nil if none of them are.
+*/
+func accessorPath(tokenPath []string) ([]string, []bool) {
+
+	var path []string
+	var optional []bool
+
+	for i, name := range tokenPath {
+
+		if !strings.HasPrefix(name, "?") {
+			continue
+		}
+
+		if optional == nil {
+			path = make([]string, len(tokenPath))
+			copy(path, tokenPath)
+			optional = make([]bool, len(tokenPath))
+		}
+
+		path[i] = name[1:]
+		optional[i] = true
+	}
+
+	if optional == nil {
+		return tokenPath, nil
+	}
+	return path, optional
+}
+
 /*
 A truly special precedence function, this handles all the "lowest-case" errata of the process, including literals, parmeters,
 clauses, and prefixes.
@@ -394,6 +696,7 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 		if stream.index > 1 {
 			prev = stream.tokens[stream.index-2]
 		}
+		tokenIndex := stream.index - 1
 
 		ret, err = planTokens(stream)
 		if err != nil {
@@ -406,6 +709,9 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 			// We need to copy this in case we are using the cached value...
 			tmp := *ret
 			tmp.operator = ensureSliceStage(ret.operator)
+			if ret.written != nil {
+				tmp.written = []interface{}{ret.written}
+			}
 			ret = &tmp
 		}
 
@@ -419,20 +725,34 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 			rightStage: ret,
 			operator:   noopStageRight,
 			symbol:     NOOP,
+			tokenIndex: tokenIndex,
 		}
 
 		return ret, nil
 
 	case CLAUSE_CLOSE:
+		fallthrough
+	case LIST_CLOSE:
 
-		// when functions have empty params, this will be hit. In this case, we don't have any evaluation stage to do,
+		// when functions have empty params (or lists have no elements), this will be hit. In this case, we don't have any evaluation stage to do,
 		// so we just return nil so that the stage planner continues on its way.
 		stream.rewind()
 		return nil, nil
 
+	case LIST:
+		stream.rewind()
+		return planList(stream)
+
 	case VARIABLE:
 		return getParameterStage(token.Value.(string))
 
+	case ITEM:
+		return &evaluationStage{
+			symbol:     ITEM_VALUE,
+			operator:   itemStage,
+			tokenIndex: stream.index - 1,
+		}, nil
+
 	case NUMERIC:
 		fallthrough
 	case STRING:
@@ -440,9 +760,17 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	case PATTERN:
 		fallthrough
 	case BOOLEAN:
+		fallthrough
+	case NIL:
 		return getConstantStage(token.Value)
 	case TIME:
-		return getConstantStage(float64(token.Value.(time.Time).Unix()))
+		// not shared with other literals, since the stage also keeps the time as it was written.
+		written := token.Value.(time.Time)
+		return &evaluationStage{
+			symbol:   LITERAL,
+			operator: makeLiteralStage(unixSeconds(written)),
+			written:  written,
+		}, nil
 
 	case PREFIX:
 		stream.rewind()
@@ -460,6 +788,42 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	}, nil
 }
 
+/*
+Plans a list literal, such as `{1, 2, 3}`.
+Its elements are kept on the left, since only the right side of a stage is reordered along with its parent.
+*/
+func planList(stream *tokenStream) (*evaluationStage, error) {
+
+	stream.next()
+	tokenIndex := stream.index - 1
+
+	elements, err := planTokens(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// advance past the LIST_CLOSE token. We know that it's a LIST_CLOSE, because at parse-time we check for unbalanced braces.
+	stream.next()
+
+	ret := &evaluationStage{
+		symbol:     LIST_LITERAL,
+		leftStage:  elements,
+		tokenIndex: tokenIndex,
+	}
+
+	// whether the elements form an array of their own, or are a single element, is decided here;
+	// at evaluation time a single element which is an array looks the same as several elements.
+	switch {
+	case elements == nil:
+		ret.operator = emptyListStage
+	case elements.symbol == SEPARATE:
+		ret.operator = noopStageLeft
+	default:
+		ret.operator = singleListStage
+	}
+	return ret, nil
+}
+
 /*
 Convenience function to pass a triplet of typechecks between `findTypeChecks` and `planPrecedenceLevel`.
 Each of these members may be nil, which indicates that type does not matter for that value.
@@ -591,7 +955,8 @@ func reorderStages(rootStage *evaluationStage) {
 
 		currentPrecedence = findOperatorPrecedenceForSymbol(currentStage.symbol)
 
-		if currentPrecedence == precedence {
+		// the key of an index (and the expression of a quantifier) is nested within it, rather than being the next operand of a chain.
+		if currentPrecedence == precedence && currentStage.symbol != INDEX_ACCESS && currentStage.symbol != QUANTIFY {
 			identicalPrecedences = append(identicalPrecedences, currentStage)
 			continue
 		}
@@ -696,6 +1061,8 @@ func elideStage(root *evaluationStage) *evaluationStage {
 	switch root.symbol {
 	case SEPARATE:
 		fallthrough
+	case QUANTIFY:
+		fallthrough
 	case IN:
 		return root
 	}
@@ -713,12 +1080,12 @@ func elideStage(root *evaluationStage) *evaluationStage {
 	}
 
 	// typcheck, since the grammar checker is a bit loose with which operator symbols go together.
-	err = typeCheck(root.leftTypeCheck, leftValue, root.symbol, root.typeErrorFormat)
+	err = typeCheck(root.leftTypeCheck, leftValue, leftValue, rightValue, root.symbol, root.typeErrorFormat)
 	if err != nil {
 		return root
 	}
 
-	err = typeCheck(root.rightTypeCheck, rightValue, root.symbol, root.typeErrorFormat)
+	err = typeCheck(root.rightTypeCheck, rightValue, leftValue, rightValue, root.symbol, root.typeErrorFormat)
 	if err != nil {
 		return root
 	}
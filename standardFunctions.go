@@ -0,0 +1,1040 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+Returns a new map of the standard library of functions, which can be merged into the functions given to `NewEvaluableExpressionWithFunctions`.
+The map belongs to the caller, and can be freely added to or removed from. See the manual for what each function does.
+*/
+func StandardFunctions() map[string]ExpressionFunction {
+
+	definitions := StandardFunctionDefinitions()
+	ret := make(map[string]ExpressionFunction, len(definitions))
+
+	for name, definition := range definitions {
+		ret[name] = definition.Function
+	}
+	return ret
+}
+
+/*
+Returns a new map of the standard library of functions, along with their signatures (see `FunctionDefinition`),
+for use as `ExpressionOptions.Functions`. Every standard function except `now` is pure.
+*/
+func StandardFunctionDefinitions() map[string]FunctionDefinition {
+
+	return map[string]FunctionDefinition{
+
+		// math
+		"abs":   standardDefinition(standardAbs, NumberType, 1, 1, NumberType),
+		"min":   collectionDefinition(standardMin, AnyType, 1, -1, AnyType),
+		"max":   collectionDefinition(standardMax, AnyType, 1, -1, AnyType),
+		"floor": standardDefinition(standardFloor, NumberType, 1, 1, NumberType),
+		"ceil":  standardDefinition(standardCeil, NumberType, 1, 1, NumberType),
+		"round": standardDefinition(standardRound, NumberType, 1, 2, NumberType, NumberType),
+		"sqrt":  standardDefinition(standardSqrt, NumberType, 1, 1, NumberType),
+		"log":   standardDefinition(standardLog, NumberType, 1, 1, NumberType),
+
+		// strings
+		"len":        collectionDefinition(standardLen, NumberType, 1, 1, AnyType),
+		"lower":      standardDefinition(standardLower, StringType, 1, 1, StringType),
+		"upper":      standardDefinition(standardUpper, StringType, 1, 1, StringType),
+		"trim":       standardDefinition(standardTrim, StringType, 1, 1, StringType),
+		"contains":   standardDefinition(standardContains, BoolType, 2, 2, StringType, StringType),
+		"startsWith": standardDefinition(standardStartsWith, BoolType, 2, 2, StringType, StringType),
+		"endsWith":   standardDefinition(standardEndsWith, BoolType, 2, 2, StringType, StringType),
+		"split":      standardDefinition(standardSplit, ArrayType, 2, 2, StringType, StringType),
+		"replace":    standardDefinition(standardReplace, StringType, 3, 3, StringType, StringType, StringType),
+		"substr":     standardDefinition(standardSubstr, StringType, 2, 3, StringType, NumberType, NumberType),
+
+		// collections
+		"sum":      collectionDefinition(standardSum, NumberType, 0, -1, NumberType),
+		"avg":      collectionDefinition(standardAvg, NumberType, 0, -1, NumberType),
+		"first":    collectionDefinition(standardFirst, AnyType, 0, -1, AnyType),
+		"last":     collectionDefinition(standardLast, AnyType, 0, -1, AnyType),
+		"distinct": collectionDefinition(standardDistinct, ArrayType, 0, -1, AnyType),
+
+		// times
+		"now": {
+			Function: standardNow,
+			FunctionSignature: FunctionSignature{
+				Arguments: []ValueType{},
+				Returns:   TimeType,
+			},
+		},
+		"duration": standardDefinition(standardDuration, DurationType, 1, 1, AnyType),
+		"year":     standardDefinition(standardYear, NumberType, 1, 1, TimeType),
+		"month":    standardDefinition(standardMonth, NumberType, 1, 1, TimeType),
+		"day":      standardDefinition(standardDay, NumberType, 1, 1, TimeType),
+		"hour":     standardDefinition(standardHour, NumberType, 1, 1, TimeType),
+		"weekday":  standardDefinition(standardWeekday, NumberType, 1, 1, TimeType),
+		"addDays":  standardDefinition(standardAddDays, TimeType, 2, 2, TimeType, NumberType),
+		"truncate": standardDefinition(standardTruncate, TimeType, 2, 2, TimeType, AnyType),
+
+		// conversion
+		"int":    standardDefinition(standardInt, NumberType, 1, 1, AnyType),
+		"float":  standardDefinition(standardFloat, NumberType, 1, 1, AnyType),
+		"string": standardDefinition(standardString, StringType, 1, 1, AnyType),
+		"bool":   standardDefinition(standardBool, BoolType, 1, 1, AnyType),
+
+		// nil
+		"has": collectionDefinition(standardHas, BoolType, 1, 1, AnyType),
+	}
+}
+
+func standardDefinition(function ExpressionFunction, returns ValueType, minArguments int, maxArguments int, arguments ...ValueType) FunctionDefinition {
+
+	return FunctionDefinition{
+		Function: function,
+		FunctionSignature: FunctionSignature{
+			Arguments:    arguments,
+			MinArguments: minArguments,
+			MaxArguments: maxArguments,
+			Returns:      returns,
+		},
+		Pure: true,
+	}
+}
+
+/*
+Same as `standardDefinition`, for a function which takes a collection, and so keeps an array given as its only argument.
+*/
+func collectionDefinition(function ExpressionFunction, returns ValueType, minArguments int, maxArguments int, arguments ...ValueType) FunctionDefinition {
+
+	ret := standardDefinition(function, returns, minArguments, maxArguments, arguments...)
+	ret.KeepArrays = true
+	return ret
+}
+
+/*
+The standard functions which take a collection. These keep an array given as their only argument however they're given to an expression,
+including by `StandardFunctions`, which has no definitions to say so; see `keepsArrays`.
+*/
+var collectionFunctions = functionPointers(standardLen, standardHas, standardMin, standardMax,
+	standardSum, standardAvg, standardFirst, standardLast, standardDistinct)
+
+func functionPointers(functions ...ExpressionFunction) map[uintptr]bool {
+
+	ret := make(map[uintptr]bool, len(functions))
+	for _, function := range functions {
+		ret[reflect.ValueOf(function).Pointer()] = true
+	}
+	return ret
+}
+
+/*
+Every standard function, whose results (unlike those of other functions) are converted to the numeric mode of the expression they're called from.
+*/
+var standardFunctionPointers = standardDefinitionPointers()
+
+func standardDefinitionPointers() map[uintptr]bool {
+
+	var functions []ExpressionFunction
+	for _, definition := range StandardFunctionDefinitions() {
+		functions = append(functions, definition.Function)
+	}
+	return functionPointers(functions...)
+}
+
+/*
+Returns whether [function] is one of the standard functions.
+*/
+func isStandardFunction(function interface{}) bool {
+
+	standard, ok := function.(ExpressionFunction)
+	return ok && standardFunctionPointers[reflect.ValueOf(standard).Pointer()]
+}
+
+/*
+The standard functions which take a time as their first argument, where a number (such as a date literal) is a unix time.
+*/
+var timeFunctions = functionPointers(standardYear, standardMonth, standardDay, standardHour, standardWeekday, standardAddDays, standardTruncate)
+
+/*
+Returns whether [function] is one of the standard functions which take a time as their first argument.
+*/
+func takesTime(function interface{}) bool {
+
+	standard, ok := function.(ExpressionFunction)
+	return ok && timeFunctions[reflect.ValueOf(standard).Pointer()]
+}
+
+/*
+Returns whether [function] is one of the standard functions which take a collection.
+*/
+func keepsArrays(function interface{}) bool {
+
+	standard, ok := function.(ExpressionFunction)
+	return ok && collectionFunctions[reflect.ValueOf(standard).Pointer()]
+}
+
+/*
+Math
+*/
+
+func standardAbs(arguments ...interface{}) (interface{}, error) {
+
+	value, err := numberArgument("abs", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case int64:
+		if value == math.MinInt64 {
+			return uint64(math.MaxInt64) + 1, nil
+		}
+		if value < 0 {
+			return -value, nil
+		}
+		return value, nil
+	case uint64:
+		return value, nil
+	case float64:
+		return math.Abs(value), nil
+	}
+	return new(big.Rat).Abs(value.(*big.Rat)), nil
+}
+
+func standardMin(arguments ...interface{}) (interface{}, error) {
+	return extremeOf("min", elementsOf(arguments), -1)
+}
+
+func standardMax(arguments ...interface{}) (interface{}, error) {
+	return extremeOf("max", elementsOf(arguments), 1)
+}
+
+/*
+Returns the smallest (if [direction] is -1) or largest (if it's 1) of [values], which must all be numbers, or all be strings.
+*/
+func extremeOf(name string, values []interface{}, direction int) (interface{}, error) {
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Function '%s' needs at least one value", name)
+	}
+
+	ret := values[0]
+
+	for _, value := range values[1:] {
+
+		comparison, err := compareValues(name, value, ret)
+		if err != nil {
+			return nil, err
+		}
+
+		if comparison == direction {
+			ret = value
+		}
+	}
+
+	if _, err := compareValues(name, ret, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/*
+Compares two numbers (exactly, whatever their representation), or two strings.
+*/
+func compareValues(name string, left interface{}, right interface{}) (int, error) {
+
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+
+	if leftIsString && rightIsString {
+		return strings.Compare(leftString, rightString), nil
+	}
+
+	leftNumber := asDecimal(left)
+	rightNumber := asDecimal(right)
+
+	if leftNumber == nil || rightNumber == nil {
+		return 0, fmt.Errorf("Function '%s' can only compare numbers with numbers, and strings with strings, not %s and %s",
+			name, describeType(left), describeType(right))
+	}
+	return leftNumber.Cmp(rightNumber), nil
+}
+
+func standardFloor(arguments ...interface{}) (interface{}, error) {
+	return roundNumber("floor", arguments, math.Floor, floorDecimal)
+}
+
+func standardCeil(arguments ...interface{}) (interface{}, error) {
+	return roundNumber("ceil", arguments, math.Ceil, ceilDecimal)
+}
+
+/*
+The most places a number can be rounded to (either side of the decimal point), which is as many as a float can have.
+*/
+const maxRoundPlaces = 308
+
+func standardRound(arguments ...interface{}) (interface{}, error) {
+
+	if len(arguments) < 2 {
+		return roundNumber("round", arguments, math.Round, roundDecimal)
+	}
+
+	places, err := integerArgument("round", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if absInt(places) > maxRoundPlaces {
+		return nil, fmt.Errorf("Function 'round' can only round to between %d and %d places, not %d", -maxRoundPlaces, maxRoundPlaces, places)
+	}
+
+	value, err := numberArgument("round", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if float, ok := value.(float64); ok {
+
+		scale := math.Pow(10, float64(places))
+
+		// a float this large has no digits to round at this many places.
+		scaled := float * scale
+		if math.IsInf(scaled, 0) {
+			return float, nil
+		}
+		return math.Round(scaled) / scale, nil
+	}
+
+	// integers and decimals are rounded exactly.
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(places))), nil))
+	if places < 0 {
+		scale.Inv(scale)
+	}
+
+	ret := roundDecimal(new(big.Rat).Mul(asDecimal(value), scale))
+	ret.Quo(ret, scale)
+
+	if isInteger(value) {
+		return fromBigInt(ret.Num()), nil
+	}
+	return ret, nil
+}
+
+/*
+Rounds the number given as the first of [arguments] to an integer, with [float] or [decimal] depending on its representation.
+Integers are returned as they are.
+*/
+func roundNumber(name string, arguments []interface{}, float func(float64) float64, decimal func(*big.Rat) *big.Rat) (interface{}, error) {
+
+	value, err := numberArgument(name, arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case float64:
+		return float(value), nil
+	case *big.Rat:
+		return decimal(value), nil
+	}
+	return value, nil
+}
+
+func floorDecimal(value *big.Rat) *big.Rat {
+
+	// euclidean division by a positive denominator rounds towards negative infinity.
+	quotient, _ := new(big.Int).DivMod(value.Num(), value.Denom(), new(big.Int))
+	return new(big.Rat).SetInt(quotient)
+}
+
+func ceilDecimal(value *big.Rat) *big.Rat {
+
+	ret := floorDecimal(new(big.Rat).Neg(value))
+	return ret.Neg(ret)
+}
+
+/*
+Rounds [value] to the nearest integer, rounding halves away from zero (the same way as `math.Round`).
+*/
+func roundDecimal(value *big.Rat) *big.Rat {
+
+	ret := floorDecimal(new(big.Rat).Add(new(big.Rat).Abs(value), big.NewRat(1, 2)))
+
+	if value.Sign() < 0 {
+		ret.Neg(ret)
+	}
+	return ret
+}
+
+func standardSqrt(arguments ...interface{}) (interface{}, error) {
+
+	value, err := floatArgument("sqrt", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if value < 0 {
+		return nil, fmt.Errorf("Function 'sqrt' cannot take the square root of a negative number (%v)", value)
+	}
+	return math.Sqrt(value), nil
+}
+
+func standardLog(arguments ...interface{}) (interface{}, error) {
+
+	value, err := floatArgument("log", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if value <= 0 {
+		return nil, fmt.Errorf("Function 'log' cannot take the logarithm of a number which isn't positive (%v)", value)
+	}
+	return math.Log(value), nil
+}
+
+/*
+Strings
+*/
+
+/*
+Returns the number of characters in a string, or the number of elements in an array or map.
+*/
+func standardLen(arguments ...interface{}) (interface{}, error) {
+
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("Function 'len' takes one argument, got %d", len(arguments))
+	}
+
+	if value, ok := arguments[0].(string); ok {
+		return utf8.RuneCountInString(value), nil
+	}
+
+	value := reflect.ValueOf(arguments[0])
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), nil
+	}
+
+	return nil, argumentError("len", 0, "a string, array or map", arguments[0])
+}
+
+func standardLower(arguments ...interface{}) (interface{}, error) {
+	return mapString("lower", arguments, strings.ToLower)
+}
+
+func standardUpper(arguments ...interface{}) (interface{}, error) {
+	return mapString("upper", arguments, strings.ToUpper)
+}
+
+func standardTrim(arguments ...interface{}) (interface{}, error) {
+	return mapString("trim", arguments, strings.TrimSpace)
+}
+
+func mapString(name string, arguments []interface{}, function func(string) string) (interface{}, error) {
+
+	value, err := stringArgument(name, arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return function(value), nil
+}
+
+func standardContains(arguments ...interface{}) (interface{}, error) {
+	return testStrings("contains", arguments, strings.Contains)
+}
+
+func standardStartsWith(arguments ...interface{}) (interface{}, error) {
+	return testStrings("startsWith", arguments, strings.HasPrefix)
+}
+
+func standardEndsWith(arguments ...interface{}) (interface{}, error) {
+	return testStrings("endsWith", arguments, strings.HasSuffix)
+}
+
+func testStrings(name string, arguments []interface{}, function func(string, string) bool) (interface{}, error) {
+
+	values, err := stringArguments(name, arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	return function(values[0], values[1]), nil
+}
+
+func standardSplit(arguments ...interface{}) (interface{}, error) {
+
+	values, err := stringArguments("split", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(values[0], values[1])
+	ret := make([]interface{}, len(parts))
+
+	for i, part := range parts {
+		ret[i] = part
+	}
+	return ret, nil
+}
+
+func standardReplace(arguments ...interface{}) (interface{}, error) {
+
+	values, err := stringArguments("replace", arguments, 3)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Replace(values[0], values[1], values[2], -1), nil
+}
+
+/*
+Returns the characters of a string from a start index, up to an optional length. Both are clamped to the bounds of the string.
+*/
+func standardSubstr(arguments ...interface{}) (interface{}, error) {
+
+	value, err := stringArgument("substr", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := integerArgument("substr", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	characters := []rune(value)
+	start = clampInt(start, 0, len(characters))
+	end := len(characters)
+
+	if len(arguments) > 2 {
+
+		length, err := integerArgument("substr", arguments, 2)
+		if err != nil {
+			return nil, err
+		}
+		end = clampInt(start+clampInt(length, 0, end), start, end)
+	}
+
+	return string(characters[start:end]), nil
+}
+
+/*
+Collections
+*/
+
+func standardSum(arguments ...interface{}) (interface{}, error) {
+
+	values := elementsOf(arguments)
+
+	var ret interface{} = int64(0)
+
+	for i := range values {
+
+		value, err := numberArgument("sum", values, i)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = addNumbers(ret, value)
+	}
+	return ret, nil
+}
+
+func standardAvg(arguments ...interface{}) (interface{}, error) {
+
+	values := elementsOf(arguments)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Function 'avg' needs at least one value")
+	}
+
+	sum, err := standardSum(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int64(len(values))
+
+	if decimal, ok := sum.(*big.Rat); ok {
+		return new(big.Rat).Quo(decimal, new(big.Rat).SetInt64(count)), nil
+	}
+	return integerDivideStage(sum, count, nil)
+}
+
+/*
+Adds two numbers, keeping integers exact for as long as they fit, and decimals exact always.
+*/
+func addNumbers(left interface{}, right interface{}) interface{} {
+
+	_, leftDecimal := left.(*big.Rat)
+	_, rightDecimal := right.(*big.Rat)
+
+	if leftDecimal || rightDecimal {
+		return new(big.Rat).Add(asDecimal(left), asDecimal(right))
+	}
+
+	ret, _ := integerAddStage(left, right, nil)
+	return ret
+}
+
+func standardFirst(arguments ...interface{}) (interface{}, error) {
+
+	values := elementsOf(arguments)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values[0], nil
+}
+
+func standardLast(arguments ...interface{}) (interface{}, error) {
+
+	values := elementsOf(arguments)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values[len(values)-1], nil
+}
+
+/*
+Returns the values with duplicates removed, in the order they first appear. Numbers are equal if they have the same value.
+*/
+func standardDistinct(arguments ...interface{}) (interface{}, error) {
+
+	ret := make([]interface{}, 0)
+
+	for _, value := range elementsOf(arguments) {
+
+		duplicate := false
+		for _, existing := range ret {
+			if decimalsEqual(value, existing) {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			ret = append(ret, value)
+		}
+	}
+	return ret, nil
+}
+
+/*
+Times
+*/
+
+func standardNow(arguments ...interface{}) (interface{}, error) {
+	return time.Now(), nil
+}
+
+/*
+Converts a string such as "72h" or "1h30m" (see `time.ParseDuration`), or a number of seconds, to a duration.
+*/
+func standardDuration(arguments ...interface{}) (interface{}, error) {
+	return durationArgument("duration", arguments, 0)
+}
+
+func standardYear(arguments ...interface{}) (interface{}, error) {
+	return timePart("year", arguments, func(value time.Time) int { return value.Year() })
+}
+
+func standardMonth(arguments ...interface{}) (interface{}, error) {
+	return timePart("month", arguments, func(value time.Time) int { return int(value.Month()) })
+}
+
+func standardDay(arguments ...interface{}) (interface{}, error) {
+	return timePart("day", arguments, func(value time.Time) int { return value.Day() })
+}
+
+func standardHour(arguments ...interface{}) (interface{}, error) {
+	return timePart("hour", arguments, func(value time.Time) int { return value.Hour() })
+}
+
+func standardWeekday(arguments ...interface{}) (interface{}, error) {
+	return timePart("weekday", arguments, func(value time.Time) int { return int(value.Weekday()) })
+}
+
+func timePart(name string, arguments []interface{}, part func(time.Time) int) (interface{}, error) {
+
+	value, err := timeArgument(name, arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return part(value), nil
+}
+
+func standardAddDays(arguments ...interface{}) (interface{}, error) {
+
+	value, err := timeArgument("addDays", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := integerArgument("addDays", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return value.AddDate(0, 0, days), nil
+}
+
+func standardTruncate(arguments ...interface{}) (interface{}, error) {
+
+	value, err := timeArgument("truncate", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := durationArgument("truncate", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return value.Truncate(duration), nil
+}
+
+/*
+Conversion
+*/
+
+/*
+Converts a number (truncating towards zero), a numeric string, or a bool (1 or 0) to an integer.
+*/
+func standardInt(arguments ...interface{}) (interface{}, error) {
+
+	value, err := convertToNumber("int", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("Function 'int' cannot convert %v to an integer", value)
+		}
+		return fromBigInt(truncateDecimal(asDecimal(math.Trunc(value)))), nil
+	case *big.Rat:
+		return fromBigInt(truncateDecimal(value)), nil
+	}
+	return value, nil
+}
+
+/*
+Converts a number, a numeric string, or a bool (1 or 0) to a float.
+*/
+func standardFloat(arguments ...interface{}) (interface{}, error) {
+
+	value, err := convertToNumber("float", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case *big.Rat:
+		ret, _ := value.Float64()
+		return ret, nil
+	}
+	return asFloat64(value), nil
+}
+
+func convertToNumber(name string, arguments []interface{}) (interface{}, error) {
+
+	if err := checkArgument(name, arguments, 0); err != nil {
+		return nil, err
+	}
+
+	switch value := arguments[0].(type) {
+
+	case bool:
+		if value {
+			return int64(1), nil
+		}
+		return int64(0), nil
+
+	case string:
+		trimmed := strings.TrimSpace(value)
+
+		integer, err := strconv.ParseInt(trimmed, 10, 64)
+		if err == nil {
+			return integer, nil
+		}
+
+		float, err := strconv.ParseFloat(trimmed, 64)
+		if err == nil {
+			return float, nil
+		}
+		return nil, fmt.Errorf("Function '%s' cannot convert '%s' to a number", name, value)
+	}
+
+	return numberArgument(name, arguments, 0)
+}
+
+/*
+Converts any value to a string. Numbers are written without any exponent or trailing zeroes.
+*/
+func standardString(arguments ...interface{}) (interface{}, error) {
+
+	if err := checkArgument("string", arguments, 0); err != nil {
+		return nil, err
+	}
+
+	switch value := castToInteger(arguments[0]).(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case *big.Rat:
+		return formatDecimal(value), nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+/*
+Converts a bool, a number (true if it isn't zero), or a string ("true", "false", "1", "0" and the like) to a bool.
+*/
+func standardBool(arguments ...interface{}) (interface{}, error) {
+
+	if err := checkArgument("bool", arguments, 0); err != nil {
+		return nil, err
+	}
+
+	switch value := arguments[0].(type) {
+
+	case bool:
+		return value, nil
+
+	case string:
+		ret, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("Function 'bool' cannot convert '%s' to a bool", value)
+		}
+		return ret, nil
+	}
+
+	value, err := numberArgument("bool", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return asDecimal(value) == nil || asDecimal(value).Sign() != 0, nil
+}
+
+/*
+Returns true if given a value which isn't nil. Arrays aren't nil, even if they're empty.
+*/
+func standardHas(arguments ...interface{}) (interface{}, error) {
+
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("Function 'has' takes one argument, got %d", len(arguments))
+	}
+	return !isNil(arguments[0]), nil
+}
+
+/*
+Arguments
+*/
+
+/*
+Returns the argument at [index] as a number: an int64, uint64, float64 or *big.Rat, whichever the numeric mode of the expression uses.
+*/
+func numberArgument(name string, arguments []interface{}, index int) (interface{}, error) {
+
+	if err := checkArgument(name, arguments, index); err != nil {
+		return nil, err
+	}
+
+	value := castToInteger(arguments[index])
+
+	switch value.(type) {
+	case int64, uint64, float64, *big.Rat:
+		return value, nil
+	case *big.Int, *big.Float:
+		return castToDecimal(value), nil
+	}
+
+	return nil, argumentError(name, index, "a number", arguments[index])
+}
+
+func floatArgument(name string, arguments []interface{}, index int) (float64, error) {
+
+	value, err := numberArgument(name, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+
+	if decimal, ok := value.(*big.Rat); ok {
+		ret, _ := decimal.Float64()
+		return ret, nil
+	}
+	return asFloat64(value), nil
+}
+
+/*
+Returns the argument at [index], which must be a whole number, as an int.
+*/
+func integerArgument(name string, arguments []interface{}, index int) (int, error) {
+
+	value, err := numberArgument(name, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+
+	decimal := asDecimal(value)
+	if decimal == nil || !decimal.IsInt() || !decimal.Num().IsInt64() {
+		return 0, argumentError(name, index, "a whole number", arguments[index])
+	}
+	return int(decimal.Num().Int64()), nil
+}
+
+/*
+Returns the argument at [index] as a time. Numbers (such as date literals) are unix times, in seconds.
+*/
+func timeArgument(name string, arguments []interface{}, index int) (time.Time, error) {
+
+	if err := checkArgument(name, arguments, index); err != nil {
+		return time.Time{}, err
+	}
+
+	if value, ok := arguments[index].(time.Time); ok {
+		return value, nil
+	}
+
+	// numbers are converted in the zone date literals are parsed in by the expression calling the function (see `makeTimeArgumentStage`),
+	// so those which are left are only from calls outside of an expression.
+	if value, ok := timeNumber(arguments[index]); ok {
+		return unixTime(value, time.Local), nil
+	}
+	return time.Time{}, argumentError(name, index, "a time", arguments[index])
+}
+
+/*
+Returns the argument at [index] as a duration, parsing strings such as "72h", and treating numbers as seconds.
+*/
+func durationArgument(name string, arguments []interface{}, index int) (time.Duration, error) {
+
+	if err := checkArgument(name, arguments, index); err != nil {
+		return 0, err
+	}
+
+	switch value := arguments[index].(type) {
+
+	case time.Duration:
+		return value, nil
+
+	case string:
+		ret, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("Function '%s' cannot convert '%s' to a duration", name, value)
+		}
+		return ret, nil
+	}
+
+	if value, ok := timeNumber(arguments[index]); ok {
+
+		ret, err := scaleDuration(time.Second, value)
+		if err == nil {
+			return ret.(time.Duration), nil
+		}
+	}
+	return 0, argumentError(name, index, "a duration", arguments[index])
+}
+
+func stringArgument(name string, arguments []interface{}, index int) (string, error) {
+
+	if err := checkArgument(name, arguments, index); err != nil {
+		return "", err
+	}
+
+	value, ok := arguments[index].(string)
+	if !ok {
+		return "", argumentError(name, index, "a string", arguments[index])
+	}
+	return value, nil
+}
+
+/*
+Returns the first [count] arguments, all of which must be strings.
+*/
+func stringArguments(name string, arguments []interface{}, count int) ([]string, error) {
+
+	ret := make([]string, count)
+
+	for i := range ret {
+
+		value, err := stringArgument(name, arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = value
+	}
+	return ret, nil
+}
+
+/*
+Returns an error if the function called [name] wasn't given an argument at [index].
+*/
+func checkArgument(name string, arguments []interface{}, index int) error {
+
+	if index < len(arguments) {
+		return nil
+	}
+	return fmt.Errorf("Function '%s' needs argument %d, but was given %d", name, index+1, len(arguments))
+}
+
+func argumentError(name string, index int, expected string, actual interface{}) error {
+	return fmt.Errorf("Argument %d of function '%s' must be %s, not %s", index+1, name, expected, describeType(actual))
+}
+
+func describeType(value interface{}) string {
+
+	if value == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%v (%T)", value, value)
+}
+
+/*
+Returns the values given to a function which works on a collection: the elements of an array given as its only argument,
+or otherwise the arguments themselves.
+*/
+func elementsOf(arguments []interface{}) []interface{} {
+
+	if len(arguments) != 1 {
+		return arguments
+	}
+
+	value := reflect.ValueOf(arguments[0])
+
+	switch value.Kind() {
+
+	case reflect.Slice, reflect.Array:
+		ret := make([]interface{}, value.Len())
+		for i := range ret {
+			ret[i] = value.Index(i).Interface()
+		}
+		return ret
+	}
+
+	return arguments
+}
+
+func clampInt(value int, min int, max int) int {
+
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func absInt(value int) int {
+
+	if value < 0 {
+		return -value
+	}
+	return value
+}
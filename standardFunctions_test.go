@@ -0,0 +1,433 @@
+package govaluate
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of calling the standard library of functions.
+*/
+type StandardFunctionTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Numerics   NumericMode
+	Expected   interface{}
+}
+
+func TestStandardFunctions(test *testing.T) {
+
+	standardTests := []StandardFunctionTest{
+
+		// math
+		{
+			Name:     "abs",
+			Input:    "abs(-2.5) + abs(3)",
+			Expected: 5.5,
+		},
+		{
+			Name:     "min and max",
+			Input:    "min(3, 1, 2) + max(3, 1, 2)",
+			Expected: 4.0,
+		},
+		{
+			Name:       "min of an array",
+			Input:      "min(values)",
+			Parameters: map[string]interface{}{"values": []interface{}{4, 2, 8}},
+			Expected:   2.0,
+		},
+		{
+			Name:     "max of strings",
+			Input:    "max('apple', 'pear', 'fig')",
+			Expected: "pear",
+		},
+		{
+			Name:     "floor and ceil",
+			Input:    "floor(-1.5) * 10 + ceil(1.2)",
+			Expected: -18.0,
+		},
+		{
+			Name:     "round",
+			Input:    "round(2.5) + round(-2.5)",
+			Expected: 0.0,
+		},
+		{
+			Name:     "round to places",
+			Input:    "round(3.14159, 2)",
+			Expected: 3.14,
+		},
+		{
+			Name:       "round a large number to places",
+			Input:      "round(x, 308)",
+			Parameters: map[string]interface{}{"x": 1e300},
+			Expected:   1e300,
+		},
+		{
+			Name:     "sqrt",
+			Input:    "sqrt(16)",
+			Expected: 4.0,
+		},
+		{
+			Name:     "log",
+			Input:    "log(1)",
+			Expected: 0.0,
+		},
+
+		// strings
+		{
+			Name:     "len of a string",
+			Input:    "len('héllo')",
+			Expected: 5.0,
+		},
+		{
+			Name:     "lower, upper and trim",
+			Input:    "lower('ABC') + upper('def') + trim('  g  ')",
+			Expected: "abcDEFg",
+		},
+		{
+			Name:     "contains, startsWith and endsWith",
+			Input:    "contains('hello', 'ell') && startsWith('hello', 'he') && endsWith('hello', 'lo') && !contains('hello', 'z')",
+			Expected: true,
+		},
+		{
+			Name:     "split",
+			Input:    "split('a,b,c', ',')",
+			Expected: []interface{}{"a", "b", "c"},
+		},
+		{
+			Name:     "split into membership",
+			Input:    "'b' in split('a,b,c', ',')",
+			Expected: true,
+		},
+		{
+			Name:     "replace",
+			Input:    "replace('a-b-c', '-', '+')",
+			Expected: "a+b+c",
+		},
+		{
+			Name:     "substr",
+			Input:    "substr('héllo', 1) + ',' + substr('héllo', 1, 2) + ',' + substr('héllo', 3, 10) + ',' + substr('héllo', 9)",
+			Expected: "éllo,él,lo,",
+		},
+
+		// collections
+		{
+			Name:       "len of an array",
+			Input:      "len(values)",
+			Parameters: map[string]interface{}{"values": []interface{}{1, 2, 3}},
+			Expected:   3.0,
+		},
+		{
+			Name:       "len of a typed array",
+			Input:      "len(values)",
+			Parameters: map[string]interface{}{"values": []string{"abc", "def"}},
+			Expected:   2.0,
+		},
+		{
+			Name:       "len of an array of one string",
+			Input:      "len(values) + len({'abc'}) + len({})",
+			Parameters: map[string]interface{}{"values": []interface{}{"abc"}},
+			Expected:   2.0,
+		},
+		{
+			Name:       "first and last of nested arrays",
+			Input:      "len(first(values)) + len(last({{1, 2, 3}}))",
+			Parameters: map[string]interface{}{"values": []interface{}{[]interface{}{1, 2}}},
+			Expected:   5.0,
+		},
+		{
+			Name:       "sum and avg",
+			Input:      "sum(values) + avg(values)",
+			Parameters: map[string]interface{}{"values": []interface{}{1, 2, 3}},
+			Expected:   8.0,
+		},
+		{
+			Name:       "sum of a typed array",
+			Input:      "sum(values)",
+			Parameters: map[string]interface{}{"values": []int{1, 2, 3}},
+			Expected:   6.0,
+		},
+		{
+			Name:     "sum of nothing",
+			Input:    "sum()",
+			Expected: 0.0,
+		},
+		{
+			Name:     "first and last",
+			Input:    "first(1, 2, 3) * 10 + last(1, 2, 3)",
+			Expected: 13.0,
+		},
+		{
+			Name:     "distinct",
+			Input:    "distinct('a', 'b', 'a', 'c', 'b')",
+			Expected: []interface{}{"a", "b", "c"},
+		},
+
+		// conversion
+		{
+			Name:     "int",
+			Input:    "int('42') + int(-3.9) + int(true)",
+			Expected: 40.0,
+		},
+		{
+			Name:     "float",
+			Input:    "float('1.5') + float(false)",
+			Expected: 1.5,
+		},
+		{
+			Name:     "string",
+			Input:    "string(1.5) + string(10) + string(true)",
+			Expected: "1.510true",
+		},
+		{
+			Name:     "bool",
+			Input:    "bool('true') && bool(1) && !bool(0)",
+			Expected: true,
+		},
+
+		// nil
+		{
+			Name:       "has",
+			Input:      "has(a) && !has(b) && has(c) && has(d) && has({}) && !has(nil)",
+			Parameters: map[string]interface{}{"a": 0, "b": nil, "c": []interface{}{1, 2}, "d": []interface{}{}},
+			Expected:   true,
+		},
+
+		// numeric modes
+		{
+			Name:     "Integer abs",
+			Input:    "abs(-3)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: int64(3),
+		},
+		{
+			Name:     "Integer sum",
+			Input:    "sum(9223372036854775807, 1)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: uint64(9223372036854775808),
+		},
+		{
+			Name:     "Integer average",
+			Input:    "avg(1, 2)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: 1.5,
+		},
+		{
+			Name:     "Integer conversion",
+			Input:    "int(7.9)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: int64(7),
+		},
+		{
+			Name:     "Integer len",
+			Input:    "len('abc')",
+			Numerics: INTEGER_NUMERICS,
+			Expected: int64(3),
+		},
+		{
+			Name:     "Decimal sum",
+			Input:    "sum(0.1, 0.2) == 0.3",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: true,
+		},
+		{
+			Name:     "Decimal round",
+			Input:    "round(2.675, 2)",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: big.NewRat(268, 100),
+		},
+		{
+			Name:     "Decimal floor",
+			Input:    "floor(-1.5)",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: big.NewRat(-2, 1),
+		},
+		{
+			Name:     "Decimal string",
+			Input:    "string(1.10)",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: "1.1",
+		},
+	}
+
+	for _, standardTest := range standardTests {
+
+		options := ExpressionOptions{Numerics: standardTest.Numerics}
+
+		expression, err := NewEvaluableExpressionWithOptions(standardTest.Input, StandardFunctions(), options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", standardTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(standardTest.Parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", standardTest.Name, err)
+			continue
+		}
+
+		if expected, ok := standardTest.Expected.(*big.Rat); ok {
+			if actual, ok := result.(*big.Rat); !ok || actual.Cmp(expected) != 0 {
+				test.Errorf("Test '%s' failed: expected %v, got %v (%T)", standardTest.Name, expected, result, result)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(result, standardTest.Expected) {
+			test.Errorf("Test '%s' failed: expected %v (%T), got %v (%T)", standardTest.Name, standardTest.Expected, standardTest.Expected, result, result)
+		}
+	}
+}
+
+func TestStandardFunctionFailures(test *testing.T) {
+
+	inputs := []string{
+		"abs('x')",
+		"min(1, 'a')",
+		"sqrt(-1)",
+		"log(0)",
+		"lower(1)",
+		"substr('abc', 1.5)",
+		"avg()",
+		"len(1)",
+		"len(true)",
+		"len()",
+		"int('x')",
+		"bool('maybe')",
+		"round(1.5, 400)",
+		"round(1.5, -400)",
+
+		// too few arguments
+		"int()",
+		"float()",
+		"abs()",
+		"round()",
+		"lower()",
+		"string()",
+		"bool()",
+		"duration()",
+		"year()",
+		"substr('abc')",
+		"replace('a', 'b')",
+		"contains('a')",
+		"split('a')",
+		"addDays(t)",
+		"truncate(t)",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, StandardFunctions())
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		_, err = expression.Evaluate(map[string]interface{}{"t": time.Now()})
+		if err == nil {
+			test.Errorf("Expected '%s' to fail", input)
+		}
+	}
+}
+
+func TestStandardRoundPlaces(test *testing.T) {
+
+	for _, numerics := range []NumericMode{FLOAT_NUMERICS, INTEGER_NUMERICS, DECIMAL_NUMERICS} {
+
+		options := ExpressionOptions{Numerics: numerics}
+
+		for _, input := range []string{"round(15, 400)", "round(15, -1000000000)"} {
+
+			expression, err := NewEvaluableExpressionWithOptions(input, StandardFunctions(), options)
+			if err != nil {
+				test.Errorf("Unable to parse '%s': %v", input, err)
+				continue
+			}
+
+			_, err = expression.Evaluate(nil)
+			if err == nil {
+				test.Errorf("Expected '%s' to fail in numeric mode %d", input, numerics)
+			}
+		}
+	}
+}
+
+func TestStandardFunctionDefinitions(test *testing.T) {
+
+	options := ExpressionOptions{
+		Functions: StandardFunctionDefinitions(),
+	}
+
+	_, err := NewEvaluableExpressionWithOptions("contains('abc')", nil, options)
+	if err == nil {
+		test.Errorf("Expected 'contains' with one argument to fail to parse")
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("round(sqrt(x), 1) > len(name)", nil, options)
+	if err != nil {
+		test.Fatalf("Unable to parse: %v", err)
+	}
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"x":    NumberType,
+			"name": StringType,
+		},
+	}
+
+	err = expression.TypeCheck(schema)
+	if err != nil {
+		test.Errorf("Expected expression to type check, got: %v", err)
+	}
+
+	residual, err := expression.PartialEval(MapParameters{"x": 2})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	expected := "1.4 > len(name)"
+	if residual.String() != expected {
+		test.Errorf("Expected '%s', got '%s'", expected, residual.String())
+	}
+
+	result, _ := expression.Evaluate(map[string]interface{}{"x": math.Pow(10, 2), "name": "abc"})
+	if result != true {
+		test.Errorf("Expected true, got %v", result)
+	}
+}
+
+func TestNonStandardFunctionResults(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"three": func(arguments ...interface{}) (interface{}, error) {
+			return 3, nil
+		},
+		"len": func(arguments ...interface{}) (interface{}, error) {
+			return len(arguments), nil
+		},
+	}
+
+	for _, input := range []string{"three()", "len(1, 2, 3)"} {
+
+		expression, _ := NewEvaluableExpressionWithFunctions(input, functions)
+
+		result, err := expression.Evaluate(nil)
+		if err != nil || result != 3 {
+			test.Errorf("Expected '%s' to give the int its function returned, got %v (%T): %v", input, result, result, err)
+		}
+	}
+}
+
+func TestStandardCollectionDefinitions(test *testing.T) {
+
+	// the definitions of collection functions should say what the functions do when given without definitions.
+	for name, definition := range StandardFunctionDefinitions() {
+		if definition.KeepArrays != keepsArrays(definition.Function) {
+			test.Errorf("Expected the definition of '%s' to have KeepArrays %v", name, keepsArrays(definition.Function))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package govaluate
+
+import (
+	"reflect"
+	"strings"
+)
+
+/*
+How the names used by accessors and indexes (such as the `Name` of `user.Name`) are matched to the fields of structs.
+See `ExpressionOptions.FieldTag` and `ExpressionOptions.CaseInsensitiveFields`.
+*/
+type fieldNaming struct {
+
+	// the struct tag whose names fields can also be found by, such as "json". Empty if fields are only found by their Go names.
+	tag string
+
+	// if true, names which only differ from a field's by case still find it.
+	caseInsensitive bool
+}
+
+func fieldNamingFor(options ExpressionOptions) fieldNaming {
+	return fieldNaming{tag: options.FieldTag, caseInsensitive: options.CaseInsensitiveFields}
+}
+
+/*
+Finds the exported field of [structType] called [name].
+Go field names are tried first, then the names given in the struct tag, then (if enabled) either of those regardless of case.
+Fields of embedded structs are found as if they belonged to the outer struct, except where a field of the outer struct has the same name.
+*/
+func (n fieldNaming) lookup(structType reflect.Type, name string) (reflect.StructField, bool) {
+
+	field, found := structType.FieldByName(name)
+	if found && field.PkgPath == "" {
+		return field, true
+	}
+
+	if n.tag == "" && !n.caseInsensitive {
+		return reflect.StructField{}, false
+	}
+
+	field, found = n.find(structType, func(field reflect.StructField) bool {
+		return n.tagName(field) == name
+	})
+	if found || !n.caseInsensitive {
+		return field, found
+	}
+
+	return n.find(structType, func(field reflect.StructField) bool {
+		if n.ignored(field) {
+			return false
+		}
+		return strings.EqualFold(field.Name, name) || strings.EqualFold(n.tagName(field), name)
+	})
+}
+
+/*
+Returns the first exported field of [structType] which [matches], looking within embedded structs (or pointers to them) after the fields of the outer one.
+The returned field's Index is its path from [structType], for use with `fieldByIndex`.
+*/
+func (n fieldNaming) find(structType reflect.Type, matches func(reflect.StructField) bool) (reflect.StructField, bool) {
+	return n.findWithin(structType, matches, map[reflect.Type]bool{})
+}
+
+func (n fieldNaming) findWithin(structType reflect.Type, matches func(reflect.StructField) bool, visited map[reflect.Type]bool) (reflect.StructField, bool) {
+
+	// a struct can embed a pointer to itself, which would otherwise be searched forever.
+	if visited[structType] {
+		return reflect.StructField{}, false
+	}
+	visited[structType] = true
+
+	var embedded []reflect.StructField
+
+	for i := 0; i < structType.NumField(); i++ {
+
+		field := structType.Field(i)
+
+		if field.Anonymous && embeddedStruct(field.Type) != nil {
+			embedded = append(embedded, field)
+		}
+
+		if field.PkgPath == "" && matches(field) {
+			return field, true
+		}
+	}
+
+	for _, outer := range embedded {
+
+		field, found := n.findWithin(embeddedStruct(outer.Type), matches, visited)
+		if found {
+			field.Index = append([]int{outer.Index[0]}, field.Index...)
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+/*
+Returns the struct type that an embedded field of [fieldType] promotes the fields of, or nil if it isn't a struct or a pointer to one.
+*/
+func embeddedStruct(fieldType reflect.Type) reflect.Type {
+
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if fieldType.Kind() != reflect.Struct {
+		return nil
+	}
+	return fieldType
+}
+
+/*
+Returns the field of the struct [value] at [index] (as given by `lookup`), following embedded pointers along the way.
+Unlike `reflect.Value.FieldByIndex`, this doesn't panic when one of those pointers is nil, and instead returns false.
+*/
+func fieldByIndex(value reflect.Value, index []int) (reflect.Value, bool) {
+
+	for i, position := range index {
+
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(position)
+	}
+	return value, true
+}
+
+/*
+Returns the name [field] is given in the struct tag, if any. Fields tagged "-" have no name.
+*/
+func (n fieldNaming) tagName(field reflect.StructField) string {
+
+	if n.tag == "" || n.ignored(field) {
+		return ""
+	}
+
+	name := field.Tag.Get(n.tag)
+	if comma := strings.Index(name, ","); comma >= 0 {
+		name = name[:comma]
+	}
+	return name
+}
+
+/*
+Returns whether [field] is tagged "-", in which case it's only found by its exact Go name.
+*/
+func (n fieldNaming) ignored(field reflect.StructField) bool {
+	return n.tag != "" && field.Tag.Get(n.tag) == "-"
+}
+
+/*
+Returns how the given [parameters] match names to fields, which is how the expression they're evaluated by was told to.
+*/
+func fieldNamingOf(parameters Parameters) fieldNaming {
+	if sanitized, ok := parameters.(*sanitizedParameters); ok {
+		return sanitized.fields
+	}
+	return fieldNaming{}
+}
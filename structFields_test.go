@@ -0,0 +1,296 @@
+package govaluate
+
+import (
+	"errors"
+	"testing"
+)
+
+type taggedAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type taggedUser struct {
+	taggedAudit
+	FirstName string            `json:"first_name" expr:"given"`
+	Age       int               `json:"age,omitempty"`
+	Secret    string            `json:"-"`
+	Address   *taggedAddress    `json:"address"`
+	Labels    map[string]string `json:"labels"`
+	nickname  string
+}
+
+type taggedOwner struct {
+	*taggedAudit
+	Team string `json:"team"`
+}
+
+type taggedNode struct {
+	*taggedNode
+	Label string `json:"label"`
+}
+
+type taggedAddress struct {
+	City string `json:"city"`
+}
+
+func (taggedUser) Greeting() string {
+	return "hello"
+}
+
+/*
+Represents a test of finding struct fields by the names in their tags, or regardless of case.
+*/
+type StructFieldTest struct {
+	Name     string
+	Input    string
+	Options  ExpressionOptions
+	Expected interface{}
+}
+
+func TestStructFields(test *testing.T) {
+
+	user := taggedUser{
+		taggedAudit: taggedAudit{CreatedBy: "admin"},
+		FirstName:   "Ada",
+		Age:         36,
+		Secret:      "hunter2",
+		Address:     &taggedAddress{City: "London"},
+		Labels:      map[string]string{"team": "core"},
+		nickname:    "ada",
+	}
+
+	parameters := map[string]interface{}{
+		"user":  user,
+		"users": []taggedUser{user},
+		"owner": taggedOwner{taggedAudit: &taggedAudit{CreatedBy: "root"}, Team: "core"},
+	}
+
+	json := ExpressionOptions{FieldTag: "json"}
+	caseInsensitive := ExpressionOptions{CaseInsensitiveFields: true}
+
+	fieldTests := []StructFieldTest{
+
+		{
+			Name:     "Go names",
+			Input:    "user.FirstName + ' ' + user.Address.City",
+			Options:  json,
+			Expected: "Ada London",
+		},
+		{
+			Name:     "Tag names",
+			Input:    "user.first_name + ' ' + user.address.city",
+			Options:  json,
+			Expected: "Ada London",
+		},
+		{
+			Name:     "Tag with options",
+			Input:    "user.age > 30",
+			Options:  json,
+			Expected: true,
+		},
+		{
+			Name:     "Tag of an embedded struct",
+			Input:    "user.created_by",
+			Options:  json,
+			Expected: "admin",
+		},
+		{
+			Name:     "Tag of an embedded pointer",
+			Input:    "owner.created_by + owner['created_by']",
+			Options:  json,
+			Expected: "rootroot",
+		},
+		{
+			Name:     "Case insensitive field of an embedded pointer",
+			Input:    "owner.createdBy",
+			Options:  caseInsensitive,
+			Expected: "root",
+		},
+		{
+			Name:     "Map after a tag name",
+			Input:    "user.labels.team",
+			Options:  json,
+			Expected: "core",
+		},
+		{
+			Name:     "Custom tag",
+			Input:    "user.given",
+			Options:  ExpressionOptions{FieldTag: "expr"},
+			Expected: "Ada",
+		},
+		{
+			Name:     "Index by tag name",
+			Input:    "user['first_name'] + users[0].address['city']",
+			Options:  json,
+			Expected: "AdaLondon",
+		},
+		{
+			Name:     "Quantifier over tag names",
+			Input:    "any(users, #.first_name == 'Ada')",
+			Options:  json,
+			Expected: true,
+		},
+		{
+			Name:     "Case insensitive",
+			Input:    "user.firstname + user.ADDRESS.city",
+			Options:  caseInsensitive,
+			Expected: "AdaLondon",
+		},
+		{
+			Name:     "Case insensitive tag names",
+			Input:    "user.First_Name + user['CREATED_BY']",
+			Options:  ExpressionOptions{FieldTag: "json", CaseInsensitiveFields: true},
+			Expected: "Adaadmin",
+		},
+		{
+			Name:     "Methods",
+			Input:    "user.Greeting()",
+			Options:  caseInsensitive,
+			Expected: "hello",
+		},
+	}
+
+	for _, fieldTest := range fieldTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(fieldTest.Input, nil, fieldTest.Options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", fieldTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", fieldTest.Name, err)
+			continue
+		}
+
+		if result != fieldTest.Expected {
+			test.Errorf("Test '%s' failed: expected %v, got %v", fieldTest.Name, fieldTest.Expected, result)
+		}
+	}
+}
+
+func TestStructFieldFailures(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"user":  taggedUser{Address: &taggedAddress{}},
+		"owner": taggedOwner{},
+		"node":  taggedNode{taggedNode: &taggedNode{}},
+	}
+
+	failures := []StructFieldTest{
+
+		{
+			Name:    "Tag names without the option",
+			Input:   "user.first_name",
+			Options: ExpressionOptions{CaseInsensitiveFields: true},
+		},
+		{
+			Name:    "Ignored field",
+			Input:   "user.secret",
+			Options: ExpressionOptions{FieldTag: "json", CaseInsensitiveFields: true},
+		},
+		{
+			Name:    "Unexported field",
+			Input:   "user.nickname",
+			Options: ExpressionOptions{FieldTag: "json", CaseInsensitiveFields: true},
+		},
+		{
+			Name:    "Different case without the option",
+			Input:   "user.firstName",
+			Options: ExpressionOptions{FieldTag: "json"},
+		},
+		{
+			Name:    "Other tag",
+			Input:   "user.given",
+			Options: ExpressionOptions{FieldTag: "json"},
+		},
+		{
+			Name:    "Tag of a nil embedded pointer",
+			Input:   "owner.created_by",
+			Options: ExpressionOptions{FieldTag: "json"},
+		},
+		{
+			Name:    "Go name of a nil embedded pointer",
+			Input:   "owner.CreatedBy",
+			Options: ExpressionOptions{FieldTag: "json"},
+		},
+		{
+			Name:    "Missing field of a struct embedding itself",
+			Input:   "node.missing",
+			Options: ExpressionOptions{FieldTag: "json", CaseInsensitiveFields: true},
+		},
+	}
+
+	for _, failure := range failures {
+
+		expression, err := NewEvaluableExpressionWithOptions(failure.Input, nil, failure.Options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", failure.Name, err)
+			continue
+		}
+
+		var accessorError *AccessorError
+		_, err = expression.Evaluate(parameters)
+
+		if !errors.As(err, &accessorError) {
+			test.Errorf("Test '%s' expected an AccessorError, got: %v", failure.Name, err)
+		}
+	}
+}
+
+func TestStructFieldIndexOfNilEmbeddedPointer(test *testing.T) {
+
+	expression, _ := NewEvaluableExpressionWithOptions("owner['created_by']", nil, ExpressionOptions{FieldTag: "json"})
+
+	var indexError *IndexError
+	_, err := expression.Evaluate(map[string]interface{}{"owner": taggedOwner{}})
+
+	if !errors.As(err, &indexError) {
+		test.Errorf("Expected an IndexError, got: %v", err)
+	}
+}
+
+func TestStructFieldTypeCheck(test *testing.T) {
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"user":  TypeOf(taggedUser{}),
+			"owner": TypeOf(taggedOwner{}),
+		},
+	}
+
+	options := ExpressionOptions{FieldTag: "json", CaseInsensitiveFields: true}
+
+	valid := []string{
+		"user.first_name + user.address.city == 'x'",
+		"user.AGE > 1 && user['created_by'] == ''",
+		"owner.created_by + owner['TEAM'] == ''",
+	}
+
+	for _, input := range valid {
+
+		expression, _ := NewEvaluableExpressionWithOptions(input, nil, options)
+
+		err := expression.TypeCheck(schema)
+		if err != nil {
+			test.Errorf("Expected '%s' to type check, got: %v", input, err)
+		}
+	}
+
+	invalid := []string{
+		"user.secret == ''",
+		"user.first_name > 1",
+		"user['nickname'] == ''",
+	}
+
+	for _, input := range invalid {
+
+		expression, _ := NewEvaluableExpressionWithOptions(input, nil, options)
+
+		err := expression.TypeCheck(schema)
+		if err == nil {
+			test.Errorf("Expected '%s' to fail type checking", input)
+		}
+	}
+}
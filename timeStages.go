@@ -0,0 +1,349 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var errTimeOperation = errors.New("unable to operate on these times, durations and numbers")
+
+/*
+Recurses through the entire tree, allowing every arithmetic and comparison stage to also operate on
+`time.Time` and `time.Duration` values. Must be used after `useNumericMode`, since it wraps the operators that mode uses.
+
+Wherever a number is used alongside a time, it's treated as a unix time, in seconds; this is how date literals are represented.
+Alongside a duration, a number can only multiply or divide it.
+*/
+func useTimeStages(root *evaluationStage) {
+
+	if root == nil {
+		return
+	}
+
+	useTimeStages(root.leftStage)
+	useTimeStages(root.rightStage)
+
+	switch root.symbol {
+
+	case PLUS, MINUS, MULTIPLY, DIVIDE, GT, LT, GTE, LTE:
+		root.operator = makeTimeStage(root.symbol, root.operator)
+		root.leftTypeCheck = allowTimes(root.leftTypeCheck)
+		root.rightTypeCheck = allowTimes(root.rightTypeCheck)
+		root.typeCheck = timeCombinedTypeCheck(root.symbol, root.typeCheck)
+
+	case EQ, NEQ:
+		root.operator = makeTimeStage(root.symbol, root.operator)
+
+	case NEGATE:
+		root.operator = makeDurationNegateStage(root.operator)
+		root.rightTypeCheck = allowDurations(root.rightTypeCheck)
+	}
+}
+
+/*
+Returns an operator which negates durations, and otherwise uses [operator].
+*/
+func makeDurationNegateStage(operator evaluationOperator) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		if duration, isDuration := right.(time.Duration); isDuration {
+			return -duration, nil
+		}
+		return operator(left, right, parameters)
+	}
+}
+
+/*
+Extends a type check to also accept durations, for operators (like negation) which work on durations but not times.
+*/
+func allowDurations(check stageTypeCheck) stageTypeCheck {
+
+	if check == nil {
+		return nil
+	}
+
+	return func(value interface{}) bool {
+		_, isDuration := value.(time.Duration)
+		return isDuration || check(value)
+	}
+}
+
+/*
+Returns an operator which works on times and durations the same way as `timeOperation`, and otherwise uses [operator].
+*/
+func makeTimeStage(symbol OperatorSymbol, operator evaluationOperator) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		// strings are concatenated with anything, times included.
+		if (!isTimeValue(left) && !isTimeValue(right)) || (symbol == PLUS && (isString(left) || isString(right))) {
+			return operator(left, right, parameters)
+		}
+		return timeOperation(symbol, left, right)
+	}
+}
+
+/*
+Extends a type check on one side of an operator to also accept times and durations.
+Whether they can be used with whatever is on the other side is decided by `timeCombinedTypeCheck`.
+*/
+func allowTimes(check stageTypeCheck) stageTypeCheck {
+
+	if check == nil {
+		return nil
+	}
+
+	return func(value interface{}) bool {
+		return isTimeValue(value) || check(value)
+	}
+}
+
+/*
+Extends the type check of an operator which uses both of its sides to also accept the combinations of times,
+durations and numbers that `timeOperation` supports.
+*/
+func timeCombinedTypeCheck(symbol OperatorSymbol, check stageCombinedTypeCheck) stageCombinedTypeCheck {
+
+	return func(left interface{}, right interface{}) bool {
+
+		if !isTimeValue(left) && !isTimeValue(right) {
+			return check == nil || check(left, right)
+		}
+
+		if symbol == PLUS && (isString(left) || isString(right)) {
+			return true
+		}
+
+		_, err := timeOperation(symbol, timeSample(left), timeSample(right))
+		return err == nil
+	}
+}
+
+/*
+Returns a value of the same kind as [value] which `timeOperation` can be given without side effects (or division by zero).
+*/
+func timeSample(value interface{}) interface{} {
+
+	switch value.(type) {
+	case time.Time:
+		return time.Time{}
+	case time.Duration:
+		return time.Duration(1)
+	}
+
+	if _, ok := timeNumber(value); ok {
+		return 1.0
+	}
+	return value
+}
+
+/*
+Performs the operation of [symbol] between two values, at least one of which is a `time.Time` or `time.Duration`.
+*/
+func timeOperation(symbol OperatorSymbol, left interface{}, right interface{}) (interface{}, error) {
+
+	leftTime, leftIsTime := left.(time.Time)
+	rightTime, rightIsTime := right.(time.Time)
+	leftDuration, leftIsDuration := left.(time.Duration)
+	rightDuration, rightIsDuration := right.(time.Duration)
+	leftNumber, leftIsNumber := timeNumber(left)
+	rightNumber, rightIsNumber := timeNumber(right)
+
+	// numbers alongside times are unix times. Alongside durations, they're only factors.
+	// times are only compared or subtracted here, which doesn't depend on the zone numbers are converted in.
+	if leftIsNumber && rightIsTime {
+		leftTime, leftIsTime = unixTime(leftNumber, time.UTC), true
+	}
+	if rightIsNumber && leftIsTime {
+		rightTime, rightIsTime = unixTime(rightNumber, time.UTC), true
+	}
+
+	switch symbol {
+
+	case EQ, NEQ:
+		equal := false
+
+		switch {
+		case leftIsTime && rightIsTime:
+			equal = leftTime.Equal(rightTime)
+		case leftIsDuration && rightIsDuration:
+			equal = leftDuration == rightDuration
+		}
+		return equal == (symbol == EQ), nil
+
+	case GT, LT, GTE, LTE:
+		var comparison int
+
+		switch {
+		case leftIsTime && rightIsTime:
+			comparison = compareTimes(leftTime, rightTime)
+		case leftIsDuration && rightIsDuration:
+			comparison = compareDurations(leftDuration, rightDuration)
+		default:
+			return nil, errTimeOperation
+		}
+
+		switch symbol {
+		case GT:
+			return comparison > 0, nil
+		case LT:
+			return comparison < 0, nil
+		case GTE:
+			return comparison >= 0, nil
+		}
+		return comparison <= 0, nil
+
+	case PLUS:
+		switch {
+		case leftIsTime && rightIsDuration:
+			return leftTime.Add(rightDuration), nil
+		case leftIsDuration && rightIsTime:
+			return rightTime.Add(leftDuration), nil
+		case leftIsDuration && rightIsDuration:
+			return leftDuration + rightDuration, nil
+		}
+
+	case MINUS:
+		switch {
+		case leftIsTime && rightIsTime:
+			return leftTime.Sub(rightTime), nil
+		case leftIsTime && rightIsDuration:
+			return leftTime.Add(-rightDuration), nil
+		case leftIsDuration && rightIsDuration:
+			return leftDuration - rightDuration, nil
+		}
+
+	case MULTIPLY:
+		switch {
+		case leftIsDuration && rightIsNumber:
+			return scaleDuration(leftDuration, rightNumber)
+		case leftIsNumber && rightIsDuration:
+			return scaleDuration(rightDuration, leftNumber)
+		}
+
+	case DIVIDE:
+		switch {
+		case leftIsDuration && rightIsNumber:
+			if rightNumber == 0 {
+				return nil, errDivisionByZero
+			}
+			return scaleDuration(leftDuration, 1/rightNumber)
+		case leftIsDuration && rightIsDuration:
+			if rightDuration == 0 {
+				return nil, errDivisionByZero
+			}
+			return float64(leftDuration) / float64(rightDuration), nil
+		}
+	}
+
+	return nil, errTimeOperation
+}
+
+func compareTimes(left time.Time, right time.Time) int {
+
+	switch {
+	case left.Before(right):
+		return -1
+	case left.After(right):
+		return 1
+	}
+	return 0
+}
+
+func compareDurations(left time.Duration, right time.Duration) int {
+
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+func scaleDuration(duration time.Duration, factor float64) (interface{}, error) {
+
+	ret := float64(duration) * factor
+	if math.IsNaN(ret) || ret > math.MaxInt64 || ret < math.MinInt64 {
+		return nil, errTimeOperation
+	}
+	return time.Duration(ret), nil
+}
+
+/*
+Returns the given [value] as a float64, if it's a number in any numeric mode.
+*/
+func timeNumber(value interface{}) (float64, bool) {
+
+	switch value.(type) {
+	case float64, int64, uint64:
+		return asFloat64(value), true
+	}
+
+	decimal := asDecimal(value)
+	if decimal == nil {
+		return 0, false
+	}
+
+	ret, _ := decimal.Float64()
+	return ret, true
+}
+
+/*
+Converts a unix time in (possibly fractional) seconds to a time in the given [location], which should be the one date literals are parsed in.
+*/
+func unixTime(seconds float64, location *time.Location) time.Time {
+
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)).In(location)
+}
+
+/*
+Returns an operator which calls a standard function that takes a time as its first argument, such as `day`,
+converting a number given as that argument to a time in the zone date literals are parsed in, rather than the local time zone.
+*/
+func makeTimeArgumentStage(operator evaluationOperator) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		arguments, isArray := right.([]interface{})
+		if !isArray {
+			arguments = []interface{}{right}
+		}
+
+		if len(arguments) == 0 {
+			return operator(left, right, parameters)
+		}
+
+		if number, ok := timeNumber(arguments[0]); ok {
+
+			converted := make([]interface{}, len(arguments))
+			copy(converted, arguments)
+			converted[0] = unixTime(number, locationFor(parameters))
+
+			right = converted
+			if !isArray {
+				right = converted[0]
+			}
+		}
+		return operator(left, right, parameters)
+	}
+}
+
+/*
+Converts [value] to the unix time, in (possibly fractional) seconds, that numbers alongside times are treated as. The reverse of `unixTime`.
+*/
+func unixSeconds(value time.Time) float64 {
+	return float64(value.Unix()) + float64(value.Nanosecond())/1e9
+}
+
+func isTimeValue(value interface{}) bool {
+
+	switch value.(type) {
+	case time.Time, time.Duration:
+		return true
+	}
+	return false
+}
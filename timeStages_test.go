@@ -0,0 +1,409 @@
+package govaluate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+/*
+Represents a test of evaluating an expression which operates on times and durations.
+*/
+type TimeStageTest struct {
+	Name       string
+	Input      string
+	Parameters map[string]interface{}
+	Numerics   NumericMode
+	Expected   interface{}
+}
+
+func TestTimeStages(test *testing.T) {
+
+	created := time.Date(2024, time.March, 15, 13, 30, 0, 0, time.Local)
+	parameters := map[string]interface{}{
+		"created": created,
+		"updated": created.Add(90 * time.Minute),
+		"ttl":     72 * time.Hour,
+	}
+
+	timeTests := []TimeStageTest{
+
+		{
+			Name:     "Time after date literal",
+			Input:    "created > '2024-01-01'",
+			Expected: true,
+		},
+		{
+			Name:     "Date literal after time",
+			Input:    "'2024-01-01' >= created",
+			Expected: false,
+		},
+		{
+			Name:     "Time equal to date literal",
+			Input:    "created == '2024-03-15 13:30:00' && created != '2024-03-15'",
+			Expected: true,
+		},
+		{
+			Name:     "Time comparison",
+			Input:    "created < updated && updated <= updated",
+			Expected: true,
+		},
+		{
+			Name:     "Time difference",
+			Input:    "updated - created",
+			Expected: 90 * time.Minute,
+		},
+		{
+			Name:     "Time minus date literal",
+			Input:    "created - '2024-03-15'",
+			Expected: 13*time.Hour + 30*time.Minute,
+		},
+		{
+			Name:     "Elapsed time since a date literal",
+			Input:    "updated - '2024-03-15' > ttl",
+			Expected: false,
+		},
+		{
+			Name:     "Elapsed time",
+			Input:    "now() - created > duration('72h')",
+			Expected: true,
+		},
+		{
+			Name:     "Elapsed time against a duration parameter",
+			Input:    "now() - ttl > created",
+			Expected: true,
+		},
+		{
+			Name:     "Time plus duration",
+			Input:    "created + duration('90m') == updated",
+			Expected: true,
+		},
+		{
+			Name:     "Duration plus time",
+			Input:    "duration('1h') + created",
+			Expected: created.Add(time.Hour),
+		},
+		{
+			Name:     "Time minus duration",
+			Input:    "updated - duration('90m')",
+			Expected: created,
+		},
+		{
+			Name:     "Duration arithmetic",
+			Input:    "ttl - duration('12h') + duration('30m')",
+			Expected: 60*time.Hour + 30*time.Minute,
+		},
+		{
+			Name:     "Scaled duration",
+			Input:    "ttl * 2 == 2 * ttl && ttl / 3 == duration('24h')",
+			Expected: true,
+		},
+		{
+			Name:     "Duration ratio",
+			Input:    "ttl / duration('1h')",
+			Expected: 72.0,
+		},
+		{
+			Name:     "Duration comparison",
+			Input:    "ttl > duration('48h') && ttl == duration(259200)",
+			Expected: true,
+		},
+		{
+			Name:     "Concatenation",
+			Input:    "'ttl: ' + ttl",
+			Expected: "ttl: 72h0m0s",
+		},
+		{
+			Name:     "Ternary of times",
+			Input:    "created > updated ? created : updated",
+			Expected: created.Add(90 * time.Minute),
+		},
+		{
+			Name:     "Integer time comparison",
+			Input:    "created > '2024-01-01' && updated - created == duration('90m')",
+			Numerics: INTEGER_NUMERICS,
+			Expected: true,
+		},
+		{
+			Name:     "Decimal time comparison",
+			Input:    "created < '2025-01-01' && ttl * 0.5 == duration('36h')",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: true,
+		},
+
+		// functions
+		{
+			Name:     "Date parts",
+			Input:    "year(created) * 10000 + month(created) * 100 + day(created)",
+			Expected: 20240315.0,
+		},
+		{
+			Name:     "Hour and weekday",
+			Input:    "hour(created) == 13 && weekday(created) == 5",
+			Expected: true,
+		},
+		{
+			Name:     "Date parts of a literal",
+			Input:    "year('2023-06-01')",
+			Expected: 2023.0,
+		},
+		{
+			Name:     "Adding days",
+			Input:    "addDays(created, -15)",
+			Expected: time.Date(2024, time.February, 29, 13, 30, 0, 0, time.Local),
+		},
+		{
+			Name:     "Truncation",
+			Input:    "truncate(updated, '1h') == truncate(updated, duration('1h'))",
+			Expected: true,
+		},
+		{
+			Name:     "Truncation to a duration",
+			Input:    "updated - truncate(updated, ttl) < ttl",
+			Expected: true,
+		},
+		{
+			Name:     "Negated duration",
+			Input:    "-ttl == duration('-72h') && created + -ttl == created - ttl",
+			Expected: true,
+		},
+		{
+			Name:     "Decimal negated duration",
+			Input:    "-(ttl / 2)",
+			Numerics: DECIMAL_NUMERICS,
+			Expected: -36 * time.Hour,
+		},
+		{
+			Name:     "Integer date parts",
+			Input:    "month(created)",
+			Numerics: INTEGER_NUMERICS,
+			Expected: int64(3),
+		},
+	}
+
+	for _, timeTest := range timeTests {
+
+		options := ExpressionOptions{Numerics: timeTest.Numerics}
+
+		expression, err := NewEvaluableExpressionWithOptions(timeTest.Input, StandardFunctions(), options)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", timeTest.Name, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Errorf("Test '%s' failed: %v", timeTest.Name, err)
+			continue
+		}
+
+		if expected, ok := timeTest.Expected.(time.Time); ok {
+			if actual, ok := result.(time.Time); !ok || !actual.Equal(expected) {
+				test.Errorf("Test '%s' failed: expected %v, got %v (%T)", timeTest.Name, expected, result, result)
+			}
+			continue
+		}
+
+		if result != timeTest.Expected {
+			test.Errorf("Test '%s' failed: expected %v (%T), got %v (%T)", timeTest.Name, timeTest.Expected, timeTest.Expected, result, result)
+		}
+	}
+}
+
+func TestTimeStageFailures(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"created": time.Now(),
+		"ttl":     time.Hour,
+	}
+
+	inputs := []string{
+		"created + created",
+		"created * 2",
+		"created > ttl",
+		"ttl > 5",
+		"created - 'x'",
+		"ttl / 0",
+		"ttl / duration(0)",
+		"duration('soon')",
+		"year('x')",
+		"truncate(created, true)",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, StandardFunctions())
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		_, err = expression.Evaluate(parameters)
+		if err == nil {
+			test.Errorf("Expected '%s' to fail", input)
+		}
+	}
+}
+
+func TestTimeStageTypeMismatches(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"created": time.Now(),
+		"ttl":     time.Hour,
+	}
+
+	inputs := []string{
+		"5 + ttl",
+		"ttl + 5",
+		"ttl - 5",
+		"5 - ttl",
+		"ttl > 5",
+		"5 <= ttl",
+		"'2024-01-01' + ttl",
+		"ttl + '2024-01-01'",
+		"-created",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, StandardFunctions())
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		var mismatch *TypeMismatchError
+
+		_, err = expression.Evaluate(parameters)
+		if !errors.As(err, &mismatch) {
+			test.Errorf("Expected '%s' to fail with a type mismatch, got: %v", input, err)
+		}
+	}
+}
+
+func TestTimeTypeCheck(test *testing.T) {
+
+	options := ExpressionOptions{
+		Functions: StandardFunctionDefinitions(),
+	}
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"created": TimeType,
+			"ttl":     DurationType,
+		},
+	}
+
+	valid := []string{
+		"created > '2024-01-01'",
+		"now() - created > duration('72h')",
+		"created + ttl < now() && ttl * 2 > ttl",
+		"year(created) > 2000 && hour(addDays(created, 1)) < 12",
+		"truncate(created, ttl) == created",
+		"created + -ttl < created",
+	}
+
+	for _, input := range valid {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		err = expression.TypeCheck(schema)
+		if err != nil {
+			test.Errorf("Expected '%s' to type check, got: %v", input, err)
+		}
+	}
+
+	invalid := []string{
+		"created + created",
+		"ttl > created",
+		"year(created) > ttl",
+		"(now() - created) * created",
+		"ttl + 5 > ttl",
+		"-created",
+	}
+
+	for _, input := range invalid {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		var checkErrors TypeCheckErrors
+		err = expression.TypeCheck(schema)
+
+		if !errors.As(err, &checkErrors) {
+			test.Errorf("Expected '%s' to fail to type check, got: %v", input, err)
+		}
+	}
+}
+
+func TestTimePartialEval(test *testing.T) {
+
+	created := time.Date(2024, time.March, 15, 13, 30, 0, 0, time.Local)
+
+	expression, err := NewEvaluableExpression("created > '2024-01-01' && updated > created")
+	if err != nil {
+		test.Fatalf("Unable to parse: %v", err)
+	}
+
+	residual, err := expression.PartialEval(MapParameters{"created": created})
+	if err != nil {
+		test.Fatalf("Unable to partially evaluate: %v", err)
+	}
+
+	result, err := residual.Evaluate(map[string]interface{}{"updated": created.Add(time.Second)})
+	if err != nil || result != true {
+		test.Errorf("Expected '%s' to be true, got %v (%v)", residual.String(), result, err)
+	}
+
+	result, err = residual.Evaluate(map[string]interface{}{"updated": created.Add(-time.Second)})
+	if err != nil || result != false {
+		test.Errorf("Expected '%s' to be false, got %v (%v)", residual.String(), result, err)
+	}
+}
+
+func TestTimeFunctionsInLocation(test *testing.T) {
+
+	// date literals are parsed in UTC, so their parts shouldn't depend on the machine's time zone.
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	defer func() { time.Local = local }()
+
+	options := ExpressionOptions{
+		Functions: StandardFunctionDefinitions(),
+		Location:  time.UTC,
+	}
+
+	inputs := []string{
+		"day('2024-03-01') == 1",
+		"hour('2024-03-01 00:30') == 0",
+		"month(addDays('2024-03-01', 30)) == 3",
+		"truncate('2024-03-01 10:00', '24h') == '2024-03-01'",
+		"weekday(1709251200) == 5",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Errorf("Unable to parse '%s': %v", input, err)
+			continue
+		}
+
+		result, err := expression.Evaluate(nil)
+		if err != nil || result != true {
+			test.Errorf("Expected '%s' to be true, got %v (%v)", input, result, err)
+		}
+
+		residual, err := expression.PartialEval(nil)
+		if err != nil || residual.String() != "true" {
+			test.Errorf("Expected '%s' to partially evaluate to true, got '%v' (%v)", input, residual, err)
+		}
+	}
+}
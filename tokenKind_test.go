@@ -18,16 +18,25 @@ func TestTokenKindStrings(test *testing.T) {
 		PREFIX,
 		NUMERIC,
 		BOOLEAN,
+		NIL,
 		STRING,
 		PATTERN,
 		TIME,
 		VARIABLE,
+		ITEM,
+		QUANTIFIER,
 		COMPARATOR,
 		LOGICALOP,
 		MODIFIER,
 		CLAUSE,
 		CLAUSE_CLOSE,
+		LIST,
+		LIST_CLOSE,
+		INDEX,
+		INDEX_CLOSE,
 		TERNARY,
+		ACCESSOR,
+		FIELD,
 	}
 
 	for _, kind := range kinds {
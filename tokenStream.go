@@ -6,6 +6,9 @@ type tokenStream struct {
 	tokens      []ExpressionToken
 	index       int
 	tokenLength int
+
+	// where each token came from in the source expression. May be nil.
+	positions *tokenPositions
 }
 
 var tokenStreamPool = sync.Pool{
@@ -19,6 +22,7 @@ func newTokenStream(tokens []ExpressionToken) *tokenStream {
 	ret.tokens = tokens
 	ret.index = 0
 	ret.tokenLength = len(tokens)
+	ret.positions = nil
 	return ret
 }
 
@@ -0,0 +1,540 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"unicode"
+)
+
+/*
+Checks this expression against the parameter and function types declared in [schema], without evaluating it.
+Operators are checked with the same rules they use when the expression is evaluated, so that something like `"foo" > 3` or `active + 1`
+(where `active` is a bool) is found up front. Accessors and indexes are checked against the Go types of structs and maps, when those are given.
+
+Every parameter the expression uses must be declared; use `AnyType` for parameters whose type isn't known.
+Anything whose type isn't known (such as the result of a function without a signature) is assumed to be usable anywhere.
+
+Returns nil if no problems were found. Otherwise, returns a TypeCheckErrors which contains every problem found,
+each of which points at the part of the expression it was found in.
+*/
+func (e EvaluableExpression) TypeCheck(schema TypeSchema) error {
+
+	stage, err := e.syntaxTree()
+	if err != nil {
+		return err
+	}
+
+	checker := &typeChecker{
+		expression: e,
+		schema:     schema,
+		reported:   make(map[string]bool),
+	}
+
+	checker.check(stage)
+
+	if len(checker.errors) == 0 {
+		return nil
+	}
+	return checker.errors
+}
+
+type typeChecker struct {
+	expression EvaluableExpression
+	schema     TypeSchema
+	errors     TypeCheckErrors
+
+	// undeclared parameters which have already been reported, so that each is only reported once.
+	reported map[string]bool
+
+	// the types of the elements that the quantifiers being checked look at, innermost last.
+	items []ValueType
+}
+
+/*
+Checks [stage] and everything beneath it, and returns the type of value it produces.
+*/
+func (c *typeChecker) check(stage *evaluationStage) ValueType {
+
+	if stage == nil {
+		return AnyType
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return typeOfValue(value)
+
+	case VALUE:
+		return c.parameter(stage.name)
+
+	case ACCESS:
+		for _, argument := range argumentStages(stage.rightStage) {
+			c.check(argument)
+		}
+		return c.accessor(stage)
+
+	case FUNCTIONAL:
+		return c.function(stage)
+
+	case NOOP:
+		return c.check(stage.rightStage)
+
+	case SEPARATE:
+		c.check(stage.leftStage)
+		c.check(stage.rightStage)
+		return ArrayType
+
+	case LIST_LITERAL:
+		c.check(stage.leftStage)
+		return ArrayType
+
+	case INDEX_ACCESS:
+		return c.index(stage)
+
+	case QUANTIFY:
+		return c.quantifier(stage)
+
+	case ITEM_VALUE:
+		if len(c.items) == 0 {
+			return AnyType
+		}
+		return c.items[len(c.items)-1]
+	}
+
+	left, right := AnyType, AnyType
+
+	if stage.leftStage != nil {
+		left = c.check(stage.leftStage)
+	}
+	if stage.rightStage != nil {
+		right = c.check(stage.rightStage)
+	}
+
+	c.checkOperator(stage, left, right)
+	return operatorResultType(stage.symbol, left, right)
+}
+
+/*
+Runs the same type checks that [stage] runs at evaluation time, using examples of the [left] and [right] types.
+*/
+func (c *typeChecker) checkOperator(stage *evaluationStage, left ValueType, right ValueType) {
+
+	operator := operatorText(stage.symbol)
+
+	if stage.leftTypeCheck != nil && left.Kind != ANY_TYPE && !stage.leftTypeCheck(left.sample()) {
+		message := fmt.Sprintf("Value of type %s cannot be used on the left of the operator '%s'", left, operator)
+		c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+		return
+	}
+
+	if stage.rightTypeCheck != nil && right.Kind != ANY_TYPE && !stage.rightTypeCheck(right.sample()) {
+		message := fmt.Sprintf("Value of type %s cannot be used on the right of the operator '%s'", right, operator)
+		c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+		return
+	}
+
+	if stage.typeCheck != nil && left.Kind != ANY_TYPE && right.Kind != ANY_TYPE && !stage.typeCheck(left.sample(), right.sample()) {
+		message := fmt.Sprintf("Values of type %s and %s cannot be used with the operator '%s'", left, right, operator)
+		c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+	}
+}
+
+/*
+Returns the declared type of the parameter with the given [name], reporting it if it isn't declared.
+*/
+func (c *typeChecker) parameter(name string) ValueType {
+
+	declared, found := c.schema.Parameters[name]
+	if found {
+		return declared
+	}
+
+	if !c.reported[name] {
+
+		c.reported[name] = true
+		message := fmt.Sprintf("No parameter '%s' is declared", name)
+		c.errorAt(c.findParameter(name), PARSE_UNDECLARED_PARAMETER, message)
+	}
+	return AnyType
+}
+
+/*
+Returns the index of the first token which refers to the parameter with the given [name], or -1 if there is none.
+*/
+func (c *typeChecker) findParameter(name string) int {
+
+	for i, token := range c.expression.tokens {
+
+		switch token.Kind {
+		case VARIABLE:
+			if token.Value == name {
+				return i
+			}
+		case ACCESSOR:
+			if token.Value.([]string)[0] == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+/*
+Follows the path of an accessor through the Go types of structs and maps, returning the type it ends up at.
+*/
+func (c *typeChecker) accessor(stage *evaluationStage) ValueType {
+
+	current := c.parameter(stage.path[0])
+
+	for i := 1; i < len(stage.path); i++ {
+
+		name := stage.path[i]
+
+		switch current.Kind {
+
+		case ANY_TYPE:
+			return AnyType
+
+		case STRUCT_TYPE:
+			if current.GoType == nil {
+				return AnyType
+			}
+
+			next, found := memberType(current.GoType, name, fieldNamingFor(c.expression.options))
+			if !found {
+				message := fmt.Sprintf("No method or field '%s' present on '%s', which is a %s", name, stage.path[i-1], current)
+				c.errorAt(stage.tokenIndex, PARSE_INVALID_ACCESSOR, message)
+				return AnyType
+			}
+			current = next
+
+		case MAP_TYPE:
+			if current.GoType == nil {
+				return AnyType
+			}
+			current = elementType(current.GoType)
+
+		default:
+			message := fmt.Sprintf("Unable to access '%s', '%s' is a %s, not a struct or map", name, stage.path[i-1], current)
+			c.errorAt(stage.tokenIndex, PARSE_INVALID_ACCESSOR, message)
+			return AnyType
+		}
+	}
+
+	return current
+}
+
+/*
+Returns the type of the elements of the array or map [goType], or of the array or map it points to.
+*/
+func elementType(goType reflect.Type) ValueType {
+
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	return typeOfGoType(goType.Elem())
+}
+
+/*
+Checks the array a quantifier looks at, and the expression it runs for each element, returning the type of its result.
+*/
+func (c *typeChecker) quantifier(stage *evaluationStage) ValueType {
+
+	collection := c.check(stage.leftStage)
+	item := AnyType
+
+	switch collection.Kind {
+	case ANY_TYPE:
+	case ARRAY_TYPE:
+		if collection.GoType != nil {
+			item = elementType(collection.GoType)
+		}
+	default:
+		message := fmt.Sprintf("Value of type %s cannot be used with the quantifier '%s', it is not an array", collection, stage.name)
+		c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+	}
+
+	c.items = append(c.items, item)
+	result := c.check(stage.rightStage)
+	c.items = c.items[:len(c.items)-1]
+
+	if stage.name == "map" {
+		return ArrayType
+	}
+
+	if result.Kind != ANY_TYPE && result.Kind != BOOL_TYPE {
+		message := fmt.Sprintf("Value of type %s cannot be used as the condition of '%s', it is not a bool", result, stage.name)
+		c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+	}
+
+	if stage.name == "filter" {
+		if collection.Kind == ARRAY_TYPE {
+			return collection
+		}
+		return ArrayType
+	}
+	return BoolType
+}
+
+/*
+Checks what an index is used on, and the key it's given, returning the type of the element it looks up.
+*/
+func (c *typeChecker) index(stage *evaluationStage) ValueType {
+
+	container := c.check(stage.leftStage)
+	key := c.check(stage.rightStage)
+
+	goType := container.GoType
+	if goType != nil && goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	switch container.Kind {
+
+	case ANY_TYPE:
+		return AnyType
+
+	case ARRAY_TYPE, STRING_TYPE:
+		if key.Kind != ANY_TYPE && key.Kind != NUMBER_TYPE {
+			message := fmt.Sprintf("Value of type %s cannot be indexed by a value of type %s, only by a number", container, key)
+			c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+		}
+
+		if container.Kind == STRING_TYPE {
+			return StringType
+		}
+		if goType == nil {
+			return AnyType
+		}
+		return typeOfGoType(goType.Elem())
+
+	case MAP_TYPE:
+		if goType == nil {
+			return AnyType
+		}
+		return typeOfGoType(goType.Elem())
+
+	case STRUCT_TYPE:
+		if key.Kind != ANY_TYPE && key.Kind != STRING_TYPE {
+			message := fmt.Sprintf("Value of type %s cannot be indexed by a value of type %s, only by the name of a field", container, key)
+			c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+			return AnyType
+		}
+
+		// only fields whose names are known can be checked.
+		if goType == nil || stage.rightStage == nil || stage.rightStage.symbol != LITERAL {
+			return AnyType
+		}
+
+		value, _ := stage.rightStage.operator(nil, nil, nil)
+		name := value.(string)
+
+		field, found := fieldNamingFor(c.expression.options).lookup(goType, name)
+		if !found {
+			message := fmt.Sprintf("No field '%s' present on %s", name, container)
+			c.errorAt(stage.tokenIndex, PARSE_INVALID_ACCESSOR, message)
+			return AnyType
+		}
+		return typeOfGoType(field.Type)
+	}
+
+	message := fmt.Sprintf("Value of type %s cannot be indexed, it is not an array, map, string or struct", container)
+	c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+	return AnyType
+}
+
+/*
+Checks the arguments given to a function against its signature, if it has one, and returns the type it returns.
+*/
+func (c *typeChecker) function(stage *evaluationStage) ValueType {
+
+	arguments := argumentStages(stage.rightStage)
+	types := make([]ValueType, len(arguments))
+
+	for i, argument := range arguments {
+		types[i] = c.check(argument)
+	}
+
+	signature, found := c.signature(stage.name)
+	if !found {
+		return AnyType
+	}
+
+	message := signature.arityError(stage.name, len(arguments))
+	if message != "" {
+		c.errorAt(stage.tokenIndex, PARSE_ARGUMENT_COUNT, message)
+		return signature.Returns
+	}
+
+	for i, actual := range types {
+
+		declared := signature.argumentType(i)
+
+		// numbers are unix times wherever times are expected, since that's how date literals are represented.
+		if declared.Kind == TIME_TYPE && actual.Kind == NUMBER_TYPE {
+			continue
+		}
+
+		if declared.Kind != ANY_TYPE && actual.Kind != ANY_TYPE && declared.Kind != actual.Kind {
+			message := fmt.Sprintf("Argument %d of function '%s' must be a %s, not a %s", i+1, stage.name, declared, actual)
+			c.errorAt(stage.tokenIndex, PARSE_TYPE_MISMATCH, message)
+		}
+	}
+
+	return signature.Returns
+}
+
+/*
+Returns the signature of the function called [name], from the schema, or else from the definition the expression was created with.
+*/
+func (c *typeChecker) signature(name string) (FunctionSignature, bool) {
+
+	signature, found := c.schema.Functions[name]
+	if found {
+		return signature, true
+	}
+
+	definition, found := c.expression.options.Functions[name]
+	return definition.FunctionSignature, found
+}
+
+func (c *typeChecker) errorAt(index int, code ParseErrorCode, message string) {
+	c.errors = append(c.errors, c.expression.positions.errorAt(index, code, message))
+}
+
+/*
+Returns the type of the result of the given operator, given the types of its operands.
+*/
+func operatorResultType(symbol OperatorSymbol, left ValueType, right ValueType) ValueType {
+
+	switch symbol {
+
+	case PLUS, MINUS, MULTIPLY, DIVIDE:
+		if !isTimeKind(left.Kind) && !isTimeKind(right.Kind) {
+			break
+		}
+
+		if symbol == PLUS && (left.Kind == STRING_TYPE || right.Kind == STRING_TYPE) {
+			return StringType
+		}
+
+		result, err := timeOperation(symbol, timeSample(left.sample()), timeSample(right.sample()))
+		if err != nil {
+			return AnyType
+		}
+		return typeOfValue(result)
+	}
+
+	switch symbol {
+
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN, AND, OR, INVERT:
+		return BoolType
+
+	case NEGATE:
+		if right.Kind == DURATION_TYPE {
+			return right
+		}
+		return NumberType
+
+	case PLUS:
+		if left.Kind == STRING_TYPE || right.Kind == STRING_TYPE {
+			return StringType
+		}
+		if left.Kind == NUMBER_TYPE && right.Kind == NUMBER_TYPE {
+			return NumberType
+		}
+		return AnyType
+
+	case MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT, BITWISE_NOT:
+		return NumberType
+
+	case TERNARY_TRUE:
+		return right
+
+	case TERNARY_FALSE, COALESCE:
+		if left == right {
+			return left
+		}
+	}
+
+	return AnyType
+}
+
+func isTimeKind(kind TypeKind) bool {
+	return kind == TIME_TYPE || kind == DURATION_TYPE
+}
+
+/*
+Returns the type of a literal value.
+*/
+func typeOfValue(value interface{}) ValueType {
+
+	switch value.(type) {
+	case *big.Rat, *big.Int, *big.Float:
+		return NumberType
+	case *regexp.Regexp:
+		// patterns are only used where strings are.
+		return StringType
+	}
+	return TypeOf(value)
+}
+
+/*
+Returns the type of the exported field or method called [name] on the struct type [goType],
+following the same rules accessors use when evaluated, where [fields] are found as they are named.
+*/
+func memberType(goType reflect.Type, name string, fields fieldNaming) (ValueType, bool) {
+
+	structType := goType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	field, found := fields.lookup(structType, name)
+	if found {
+		return typeOfGoType(field.Type), true
+	}
+
+	if unicode.ToUpper(getFirstRune(name)) != getFirstRune(name) {
+		return AnyType, false
+	}
+
+	method, found := structType.MethodByName(name)
+	if !found && goType.Kind() == reflect.Ptr {
+		method, found = goType.MethodByName(name)
+	}
+
+	if !found {
+		return AnyType, false
+	}
+
+	if method.Type.NumOut() == 0 {
+		return AnyType, true
+	}
+	return typeOfGoType(method.Type.Out(0)), true
+}
+
+/*
+Returns the stages of each argument given to a function or method, from the stage which holds its parenthesized arguments.
+*/
+func argumentStages(stage *evaluationStage) []*evaluationStage {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if stage == nil {
+		return nil
+	}
+
+	var ret []*evaluationStage
+
+	for ; stage.symbol == SEPARATE; stage = stage.leftStage {
+		ret = append([]*evaluationStage{stage.rightStage}, ret...)
+	}
+	return append([]*evaluationStage{stage}, ret...)
+}
@@ -0,0 +1,373 @@
+package govaluate
+
+import (
+	"errors"
+	"testing"
+)
+
+/*
+Represents a test of type checking an expression against a schema.
+[Expected] holds the code and column of each error expected, in order; if it's empty, the expression is expected to check cleanly.
+*/
+type TypeCheckTest struct {
+	Name     string
+	Input    string
+	Expected []TypeCheckTestError
+}
+
+type TypeCheckTestError struct {
+	Code   ParseErrorCode
+	Column int
+}
+
+func TestTypeCheck(test *testing.T) {
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"count":   NumberType,
+			"name":    StringType,
+			"active":  BoolType,
+			"created": TimeType,
+			"tags":    ArrayType,
+			"foo":     TypeOf(dummyParameter{}),
+			"fooPtr":  TypeOf(&dummyParameter{}),
+			"users":   TypeOf([]dummyParameter{}),
+			"scores":  TypeOf(&map[string]int{}),
+			"members": TypeOf(&[]dummyParameter{}),
+			"unknown": AnyType,
+		},
+		Functions: map[string]FunctionSignature{
+			"len": {
+				Arguments: []ValueType{StringType},
+				Returns:   NumberType,
+			},
+			"concat": {
+				Arguments: []ValueType{StringType, StringType},
+				Variadic:  true,
+				Returns:   StringType,
+			},
+		},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"len":    func(arguments ...interface{}) (interface{}, error) { return nil, nil },
+		"concat": func(arguments ...interface{}) (interface{}, error) { return nil, nil },
+		"opaque": func(arguments ...interface{}) (interface{}, error) { return nil, nil },
+	}
+
+	typeTests := []TypeCheckTest{
+
+		{
+			Name:  "Valid comparison",
+			Input: "count > 3 && name == 'x'",
+		},
+		{
+			Name:  "Valid string concatenation",
+			Input: "name + count",
+		},
+		{
+			Name:  "Valid membership",
+			Input: "name in ('a', 'b') || name in tags",
+		},
+		{
+			Name:  "Valid regex",
+			Input: "name =~ '^a'",
+		},
+		{
+			Name:  "Valid ternary",
+			Input: "active ? count + 1 : 0",
+		},
+		{
+			Name:  "Valid accessors",
+			Input: "foo.Int > 1 && foo.Nested.Funk == 'x' && 'y' == fooPtr.Func3()",
+		},
+		{
+			Name:  "Map accessor",
+			Input: "foo.Map.anything == 1",
+		},
+		{
+			Name:  "Untyped values",
+			Input: "unknown + 1 > opaque(count) && unknown.Whatever",
+		},
+		{
+			Name:  "Valid functions",
+			Input: "len(name) > 2 && concat('a') == concat('a', 'b', name)",
+		},
+		{
+			Name:  "Comparing a string to a number",
+			Input: "name > 3",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 6},
+			},
+		},
+		{
+			Name:  "Adding to a bool",
+			Input: "active + 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 8},
+			},
+		},
+		{
+			Name:  "Logic on a number",
+			Input: "count && active",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 7},
+			},
+		},
+		{
+			Name:  "Negating a string",
+			Input: "-name",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 1},
+			},
+		},
+		{
+			Name:  "Inferred types",
+			Input: "(count * 2) + 1 > len(name) && (name + 'x') * 2 > 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 45},
+			},
+		},
+		{
+			Name:  "Accessor result types",
+			Input: "foo.String > 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 12},
+			},
+		},
+		{
+			Name:  "Method result types",
+			Input: "1 - fooPtr.Func3()",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 3},
+			},
+		},
+		{
+			Name:  "Multiple errors",
+			Input: "active + 1 > 2 || name - 1 == 0",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 8},
+				{PARSE_TYPE_MISMATCH, 24},
+			},
+		},
+		{
+			Name:  "Undeclared parameter",
+			Input: "count > 1 && missing > 2 && missing < 5",
+			Expected: []TypeCheckTestError{
+				{PARSE_UNDECLARED_PARAMETER, 14},
+			},
+		},
+		{
+			Name:  "Undeclared accessor root",
+			Input: "missing.Foo",
+			Expected: []TypeCheckTestError{
+				{PARSE_UNDECLARED_PARAMETER, 1},
+			},
+		},
+		{
+			Name:  "Missing field",
+			Input: "foo.Missing == 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 1},
+			},
+		},
+		{
+			Name:  "Unexported field",
+			Input: "foo.nested == 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 1},
+			},
+		},
+		{
+			Name:  "Accessing a number",
+			Input: "foo.Int.Value",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 1},
+			},
+		},
+		{
+			Name:  "Optional accessors",
+			Input: "fooPtr?.Nested?.Funk + name == '' && foo?.Int > count",
+		},
+		{
+			Name:  "Indexes",
+			Input: "tags[count] == foo.Map[name] && name[0] == foo['String'] && unknown[0].a[1]",
+		},
+		{
+			Name:  "Field after an index",
+			Input: "foo.Map['a'].b == fooPtr['Nested'].Funk + name",
+		},
+		{
+			Name:  "Index by the wrong type",
+			Input: "tags[name] == foo[count]",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 5},
+				{PARSE_TYPE_MISMATCH, 18},
+			},
+		},
+		{
+			Name:  "Index of something that can't be indexed",
+			Input: "count[0] > 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 6},
+			},
+		},
+		{
+			Name:  "Missing field after an index",
+			Input: "fooPtr['Nested'].Missing",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 17},
+			},
+		},
+		{
+			Name:  "Quantifiers",
+			Input: "any(tags, # == name) && all(foo.Map['IntArray'], #.a) && map(unknown, #)[0] && filter(tags, #)[0].a",
+		},
+		{
+			Name:  "Quantifier over a typed array",
+			Input: "none(users, #.Int > count) || len(filter(users, #.String == name)[0].Nested.Funk) > count",
+		},
+		{
+			Name:  "Pointers to a map and an array",
+			Input: "scores.math > count && scores['art'] < count && any(members, #.Int > count) && members[0].Int > count",
+		},
+		{
+			Name:  "Access on an element of a pointer to a map",
+			Input: "scores.math.x > 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 1},
+			},
+		},
+		{
+			Name:  "Missing field of an element",
+			Input: "any(users, #.Missing)",
+			Expected: []TypeCheckTestError{
+				{PARSE_INVALID_ACCESSOR, 13},
+			},
+		},
+		{
+			Name:  "Quantifier over something that isn't an array",
+			Input: "any(count, true) || any(foo, # > 1)",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 1},
+				{PARSE_TYPE_MISMATCH, 21},
+			},
+		},
+		{
+			Name:  "Quantifier condition that isn't a bool",
+			Input: "all(tags, name) && map(tags, name) != nil",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 1},
+			},
+		},
+		{
+			Name:  "Wrong argument count",
+			Input: "len(name, name) > 1",
+			Expected: []TypeCheckTestError{
+				{PARSE_ARGUMENT_COUNT, 1},
+			},
+		},
+		{
+			Name:  "Too few variadic arguments",
+			Input: "concat() == ''",
+			Expected: []TypeCheckTestError{
+				{PARSE_ARGUMENT_COUNT, 1},
+			},
+		},
+		{
+			Name:  "Wrong argument type",
+			Input: "concat('a', count) == ''",
+			Expected: []TypeCheckTestError{
+				{PARSE_TYPE_MISMATCH, 1},
+			},
+		},
+	}
+
+	for _, typeTest := range typeTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(typeTest.Input, functions)
+		if err != nil {
+			test.Errorf("Test '%s' failed to parse: %v", typeTest.Name, err)
+			continue
+		}
+
+		err = expression.TypeCheck(schema)
+
+		if len(typeTest.Expected) == 0 {
+			if err != nil {
+				test.Errorf("Test '%s' failed: %v", typeTest.Name, err)
+			}
+			continue
+		}
+
+		var checkErrors TypeCheckErrors
+		if !errors.As(err, &checkErrors) {
+			test.Errorf("Test '%s' failed: expected TypeCheckErrors, got '%v'", typeTest.Name, err)
+			continue
+		}
+
+		if len(checkErrors) != len(typeTest.Expected) {
+			test.Errorf("Test '%s' failed: expected %d errors, got %d: %v", typeTest.Name, len(typeTest.Expected), len(checkErrors), err)
+			continue
+		}
+
+		for i, expected := range typeTest.Expected {
+
+			actual := checkErrors[i]
+			if actual.Code != expected.Code || actual.Column != expected.Column {
+				test.Errorf("Test '%s' failed: expected %v at column %d, got %v at column %d (%s)",
+					typeTest.Name, expected.Code, expected.Column, actual.Code, actual.Column, actual.Message)
+			}
+		}
+	}
+}
+
+func TestTypeCheckMatchesEvaluation(test *testing.T) {
+
+	schema := TypeSchema{
+		Parameters: map[string]ValueType{
+			"a": NumberType,
+			"b": StringType,
+			"c": BoolType,
+		},
+	}
+
+	parameters := map[string]interface{}{
+		"a": 1,
+		"b": "x",
+		"c": true,
+	}
+
+	inputs := []string{
+		"a > b",
+		"c + 1",
+		"b - a",
+		"!a",
+		"~b",
+		"c && a",
+		"a =~ b",
+		"a ** c",
+		"a > 1 && b == 'x'",
+		"b + a",
+		"c ? a : b",
+		"b[0] + b",
+		"b[b]",
+		"a[0]",
+		"any(a, true)",
+		"map({a, b}, # + 1)",
+		"map(b, #)",
+		"all({b}, # != a)",
+	}
+
+	for _, input := range inputs {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		_, evaluationErr := expression.Evaluate(parameters)
+		checkErr := expression.TypeCheck(schema)
+
+		if (evaluationErr == nil) != (checkErr == nil) {
+			test.Errorf("'%s' evaluated with error '%v', but type checked with error '%v'", input, evaluationErr, checkErr)
+		}
+	}
+}